@@ -13,6 +13,7 @@ import (
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/log"
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/server"
 	_ "github.com/WuKongIM/WuKongIMBusinessExtra/internal"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/base"
 	"github.com/gin-gonic/gin"
 	"github.com/judwhite/go-svc"
 	"github.com/robfig/cron"
@@ -48,6 +49,7 @@ func main() {
 	cfg := config.New()
 	cfg.Version = Version
 	cfg.ConfigureWithViper(vp)
+	base.SetupConfig(vp)
 
 	// 初始化context
 	ctx := config.NewContext(cfg)
@@ -101,6 +103,11 @@ func runAPI(ctx *config.Context) {
 	// if err != nil {
 	// 	panic(err)
 	// }
+	// 模块注册的定时任务
+	err = base.SetupCron(cn)
+	if err != nil {
+		panic(err)
+	}
 	cn.Start()
 
 	// 打印服务器信息
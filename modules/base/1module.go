@@ -0,0 +1,17 @@
+package base
+
+import (
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/register"
+)
+
+func init() {
+
+	// base被其他模块依赖，所以会最先注册，保证其他模块创建前IM客户端已按配置初始化
+	register.AddModule(func(ctx interface{}) register.Module {
+		SetupIM(ctx.(*config.Context).GetConfig())
+		return register.Module{
+			Name: "base",
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package base
+
+import (
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Config 业务扩展的配置（唐僧叨叨库配置之外的配置项）
+type Config struct {
+	// ---------- message ----------
+	Message struct {
+		RevokeTimeout time.Duration // 发送者可撤回消息的时间，0表示不限制（群主和管理员不受限制）
+	}
+	// ---------- user ----------
+	User struct {
+		OnlineNotifyLimit int // 在线状态变化时最多通知的同群成员数，0表示不通知
+	}
+	// ---------- webhook ----------
+	Webhook struct {
+		Secret     string        // 与悟空IM约定的签名密钥，为空表示不校验签名
+		SignExpire time.Duration // 签名有效期，超过有效期的请求视为重放
+	}
+}
+
+// NewConfig 默认配置
+func NewConfig() *Config {
+	cfg := &Config{}
+	cfg.Message.RevokeTimeout = time.Minute * 2
+	cfg.User.OnlineNotifyLimit = 500
+	cfg.Webhook.SignExpire = time.Minute * 5
+	return cfg
+}
+
+// ConfigureWithViper 从配置文件或环境变量加载配置
+func (c *Config) ConfigureWithViper(vp *viper.Viper) {
+	if vp.IsSet("message.revokeTimeout") {
+		c.Message.RevokeTimeout = vp.GetDuration("message.revokeTimeout")
+	}
+	if vp.IsSet("user.onlineNotifyLimit") {
+		c.User.OnlineNotifyLimit = vp.GetInt("user.onlineNotifyLimit")
+	}
+	c.Webhook.Secret = vp.GetString("webhook.secret")
+	if vp.IsSet("webhook.signExpire") {
+		c.Webhook.SignExpire = vp.GetDuration("webhook.signExpire")
+	}
+}
+
+var (
+	extraConfig     = NewConfig()
+	extraConfigLock sync.RWMutex
+)
+
+// SetupConfig 加载业务扩展配置
+func SetupConfig(vp *viper.Viper) {
+	cfg := NewConfig()
+	cfg.ConfigureWithViper(vp)
+
+	extraConfigLock.Lock()
+	extraConfig = cfg
+	extraConfigLock.Unlock()
+}
+
+// GetConfig 获取业务扩展配置
+func GetConfig() *Config {
+	extraConfigLock.RLock()
+	defer extraConfigLock.RUnlock()
+	return extraConfig
+}
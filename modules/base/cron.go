@@ -0,0 +1,37 @@
+package base
+
+import (
+	"sync"
+
+	"github.com/robfig/cron"
+)
+
+type cronJob struct {
+	spec string
+	fnc  func()
+}
+
+var (
+	cronJobs     []cronJob
+	cronJobsLock sync.Mutex
+)
+
+// AddCronFunc 注册定时任务，spec为cron表达式（包含秒）
+// 需要在模块安装阶段调用，任务会在api服务启动时加入到定时器内
+func AddCronFunc(spec string, fnc func()) {
+	cronJobsLock.Lock()
+	defer cronJobsLock.Unlock()
+	cronJobs = append(cronJobs, cronJob{spec: spec, fnc: fnc})
+}
+
+// SetupCron 将已注册的定时任务添加到定时器
+func SetupCron(cn *cron.Cron) error {
+	cronJobsLock.Lock()
+	defer cronJobsLock.Unlock()
+	for _, job := range cronJobs {
+		if err := cn.AddFunc(job.spec, job.fnc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
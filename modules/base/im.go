@@ -0,0 +1,33 @@
+package base
+
+import (
+	"sync"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/pkg/imclient"
+)
+
+var (
+	imClient     = imclient.New(imclient.NewOptions())
+	imClientLock sync.RWMutex
+)
+
+// SetupIM 根据配置初始化悟空IM客户端
+func SetupIM(cfg *config.Config) {
+	opts := imclient.NewOptions()
+	if cfg.WuKongIM.APIURL != "" {
+		opts.APIURL = cfg.WuKongIM.APIURL
+	}
+	opts.ManagerToken = cfg.WuKongIM.ManagerToken
+
+	imClientLock.Lock()
+	imClient = imclient.New(opts)
+	imClientLock.Unlock()
+}
+
+// IM 获取悟空IM客户端
+func IM() *imclient.Client {
+	imClientLock.RLock()
+	defer imClientLock.RUnlock()
+	return imClient
+}
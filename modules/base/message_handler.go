@@ -0,0 +1,93 @@
+package base
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/common"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+)
+
+// MessageHandler 消息处理者，IM的每条消息（msg.notify）都会分发给匹配的处理者
+type MessageHandler struct {
+	Name         string                               // 处理者名称（用于日志）
+	ContentTypes []common.ContentType                 // 处理的正文类型，为空表示处理所有类型
+	Handle       func(messages []*config.MessageResp) // 处理消息，在任务池内异步执行
+}
+
+// Match 是否处理此正文类型
+func (m MessageHandler) Match(contentType common.ContentType) bool {
+	if len(m.ContentTypes) == 0 {
+		return true
+	}
+	for _, ct := range m.ContentTypes {
+		if ct == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter 过滤出需要处理的消息
+func (m MessageHandler) Filter(messages []*config.MessageResp) []*config.MessageResp {
+	if len(m.ContentTypes) == 0 {
+		return messages
+	}
+	filtered := make([]*config.MessageResp, 0, len(messages))
+	for _, message := range messages {
+		if m.Match(contentTypeOf(message)) {
+			filtered = append(filtered, message)
+		}
+	}
+	return filtered
+}
+
+// 获取消息的正文类型，正文格式有误或没有数字类型的type时返回0
+// （不使用MessageResp.GetContentType，type不是数字时会panic）
+func contentTypeOf(message *config.MessageResp) common.ContentType {
+	payloadMap, err := message.GetPayloadMap()
+	if err != nil {
+		return 0
+	}
+	contentTypeNum, _ := payloadMap["type"].(json.Number)
+	contentTypeI64, _ := contentTypeNum.Int64()
+	return common.ContentType(contentTypeI64)
+}
+
+var (
+	messageHandlers     = make([]MessageHandler, 0)
+	messageHandlersLock sync.RWMutex
+)
+
+// AddMessageHandler 添加消息处理者（在模块创建时添加），同名的处理者会被替换
+func AddMessageHandler(handler MessageHandler) {
+	messageHandlersLock.Lock()
+	defer messageHandlersLock.Unlock()
+	handlers := make([]MessageHandler, 0, len(messageHandlers)+1)
+	for _, h := range messageHandlers {
+		if h.Name != handler.Name {
+			handlers = append(handlers, h)
+		}
+	}
+	messageHandlers = append(handlers, handler)
+}
+
+// RemoveMessageHandler 移除消息处理者
+func RemoveMessageHandler(name string) {
+	messageHandlersLock.Lock()
+	defer messageHandlersLock.Unlock()
+	handlers := make([]MessageHandler, 0, len(messageHandlers))
+	for _, h := range messageHandlers {
+		if h.Name != name {
+			handlers = append(handlers, h)
+		}
+	}
+	messageHandlers = handlers
+}
+
+// GetMessageHandlers 获取所有消息处理者
+func GetMessageHandlers() []MessageHandler {
+	messageHandlersLock.RLock()
+	defer messageHandlersLock.RUnlock()
+	return messageHandlers
+}
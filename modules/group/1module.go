@@ -7,6 +7,7 @@ import (
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/common"
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/register"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/base"
 )
 
 //go:embed sql
@@ -17,6 +18,8 @@ func init() {
 
 		fmt.Println("register......")
 		api := New(ctx.(*config.Context))
+		// 每分钟解除到期的成员禁言
+		base.AddCronFunc("0 * * * * ?", api.expireMemberForbidden)
 		return register.Module{
 			Name: "group",
 			SetupAPI: func() register.APIRouter {
@@ -30,6 +33,18 @@ func init() {
 					}
 					return register.IMDatasourceTypeNone
 				},
+				ChannelInfo: func(channelID string, channelType uint8) (map[string]interface{}, error) {
+					return api.datasourceChannelInfo(channelID)
+				},
+				Subscribers: func(channelID string, channelType uint8) ([]string, error) {
+					return api.datasourceSubscribers(channelID)
+				},
+				Blacklist: func(channelID string, channelType uint8) ([]string, error) {
+					return api.datasourceBlacklist(channelID)
+				},
+				Whitelist: func(channelID string, channelType uint8) ([]string, error) {
+					return api.datasourceWhitelist(channelID)
+				},
 			},
 		}
 	})
@@ -10,8 +10,6 @@ import (
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/common"
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/log"
-	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/network"
-	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/util"
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/wkhttp"
 	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/base"
 	"go.uber.org/zap"
@@ -21,16 +19,20 @@ import (
 type Group struct {
 	ctx *config.Context
 	log.Log
-	db *DB
+	db       *DB
+	memberDB *memberDB
+	listDB   *listDB
 }
 
 // New New
 func New(ctx *config.Context) *Group {
 
 	g := &Group{
-		ctx: ctx,
-		Log: log.NewTLog("Group"),
-		db:  NewDB(ctx),
+		ctx:      ctx,
+		Log:      log.NewTLog("Group"),
+		db:       NewDB(ctx),
+		memberDB: newMemberDB(ctx),
+		listDB:   newListDB(ctx),
 	}
 	return g
 }
@@ -44,6 +46,19 @@ func (g *Group) Route(r *wkhttp.WKHttp) {
 		v.GET("/groups/:group_no", g.groupGet)           // 群详情
 		v.GET("/groups/:group_no/avatar", g.groupAvatar) // 群头像
 		v.PUT("/groups/:group_no", g.groupUpdateName)    // 更新群资料
+
+		v.POST("/groups/:group_no/members", g.memberAdd)      // 邀请成员
+		v.DELETE("/groups/:group_no/members", g.memberRemove) // 移除成员
+		v.POST("/groups/:group_no/exit", g.memberExit)        // 退出群聊
+		v.GET("/groups/:group_no/members", g.memberList)      // 成员列表
+		v.GET("/groups/:group_no/members/:uid", g.memberGet)  // 成员详情
+
+		v.PUT("/groups/:group_no/transfer/:to_uid", g.transferCreator) // 转让群主
+		v.POST("/groups/:group_no/managers", g.managerAdd)             // 添加管理员
+		v.DELETE("/groups/:group_no/managers", g.managerRemove)        // 移除管理员
+
+		v.PUT("/groups/:group_no/forbidden/:on", g.groupForbidden)           // 全员禁言
+		v.POST("/groups/:group_no/forbidden_with_member", g.memberForbidden) // 禁言或解除禁言成员
 	}
 }
 
@@ -59,6 +74,10 @@ func (g *Group) groupUpdateName(c *wkhttp.Context) {
 		c.ResponseError(errors.New("群名称不能为空"))
 		return
 	}
+	if _, err := g.checkGroupManager(groupNo, req.LoginUID); err != nil {
+		c.ResponseError(err)
+		return
+	}
 	if err := g.db.updateName(req.Name, groupNo); err != nil {
 		g.Error("更新群名称失败", zap.Error(err))
 		c.ResponseError(errors.New("更新群名称失败"))
@@ -126,24 +145,65 @@ func (g *Group) create(c *wkhttp.Context) {
 			c.ResponseError(errors.New("创建群失败"))
 			return
 		}
+		err = g.memberDB.insertIgnore(&memberModel{
+			GroupNo: req.GroupNo,
+			UID:     req.LoginUID,
+			Role:    MemberRoleCreator,
+		})
+		if err != nil {
+			g.Error("添加群成员失败", zap.Error(err))
+			c.ResponseError(errors.New("添加群成员失败"))
+			return
+		}
+	} else {
+		// 群已存在时只有群成员能重复调用（重新订阅），其他人需要由群成员邀请加入
+		member, err := g.queryMemberWithLegacy(req.GroupNo, req.LoginUID)
+		if err != nil {
+			g.Error("查询群成员错误", zap.Error(err))
+			c.ResponseError(errors.New("查询群成员错误"))
+			return
+		}
+		if member == nil {
+			memberCount, err := g.memberDB.queryCount(req.GroupNo)
+			if err != nil {
+				g.Error("查询群成员数量错误", zap.Error(err))
+				c.ResponseError(errors.New("查询群成员数量错误"))
+				return
+			}
+			// 没有任何成员的群（例如创建前被查询过的群）由创建者认领
+			if model.Legacy == 1 || memberCount > 0 {
+				c.ResponseError(errors.New("群已存在，请由群成员邀请加入！"))
+				return
+			}
+			if err = g.db.updateCreator(req.LoginUID, req.GroupNo); err != nil {
+				g.Error("更新群主失败", zap.Error(err))
+				c.ResponseError(errors.New("更新群主失败"))
+				return
+			}
+			err = g.memberDB.insertIgnore(&memberModel{
+				GroupNo: req.GroupNo,
+				UID:     req.LoginUID,
+				Role:    MemberRoleCreator,
+			})
+			if err != nil {
+				g.Error("添加群成员失败", zap.Error(err))
+				c.ResponseError(errors.New("添加群成员失败"))
+				return
+			}
+		}
 	}
 	// 添加频道订阅者
-	resp, err := network.Post(base.APIURL+"/channel/subscriber_add", []byte(util.ToJson(&subscriberAddReq{
+	err = base.IM().AddSubscribers(&config.SubscriberAddReq{
 		ChannelID:   req.GroupNo,
-		ChannelType: 2,
+		ChannelType: common.ChannelTypeGroup.Uint8(),
 		Reset:       0,
 		Subscribers: []string{req.LoginUID},
-	})), nil)
+	})
 	if err != nil {
 		g.Error("添加订阅错误", zap.Error(err))
 		c.ResponseError(errors.New("添加订阅错误"))
 		return
 	}
-	err = base.HandlerIMError(resp)
-	if err != nil {
-		c.ResponseError(err)
-		return
-	}
 	c.ResponseOK()
 }
 
@@ -157,14 +217,8 @@ func (g *Group) groupGet(c *wkhttp.Context) {
 		return
 	}
 	if model == nil {
-		name := "群" + groupNo
-		err = g.db.insert(&GroupModel{GroupNo: groupNo, Name: name})
-		if err != nil {
-			g.Error("创建群失败", zap.Error(err))
-			c.ResponseError(errors.New("创建群失败"))
-			return
-		}
-		model = &GroupModel{GroupNo: groupNo, Name: name}
+		// 群还未创建时返回默认资料，不保存（群由创建接口创建）
+		model = &GroupModel{GroupNo: groupNo, Name: "群" + groupNo}
 	}
 	avatar := fmt.Sprintf("groups/%s/avatar", groupNo)
 	c.Response(&groupResp{
@@ -187,11 +241,3 @@ type createReq struct {
 	GroupNo  string `json:"group_no"`
 	LoginUID string `json:"login_uid"`
 }
-
-// subscriberAddReq 添加订阅请求
-type subscriberAddReq struct {
-	ChannelID   string   `json:"channel_id"`
-	ChannelType uint8    `json:"channel_type"`
-	Reset       int      `json:"reset"` // 是否重置订阅者 （0.不重置 1.重置），选择重置，将删除原来的所有成员
-	Subscribers []string `json:"subscribers"`
-}
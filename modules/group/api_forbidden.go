@@ -0,0 +1,213 @@
+package group
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/common"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/wkhttp"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/base"
+	"go.uber.org/zap"
+)
+
+// 全员禁言（开启后只有群主和管理员能发言）
+func (g *Group) groupForbidden(c *wkhttp.Context) {
+	groupNo := c.Param("group_no")
+	on := c.Param("on")
+	if on != "0" && on != "1" {
+		c.ResponseError(errors.New("参数on只能为0或1！"))
+		return
+	}
+	var req struct {
+		LoginUID string `json:"login_uid"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.ResponseError(errors.New("请求数据格式有误！"))
+		return
+	}
+	if strings.TrimSpace(req.LoginUID) == "" {
+		c.ResponseError(errors.New("登录用户ID不能为空"))
+		return
+	}
+	if _, err := g.checkGroupManager(groupNo, req.LoginUID); err != nil {
+		c.ResponseError(err)
+		return
+	}
+	forbidden := 0
+	if on == "1" {
+		forbidden = 1
+	}
+	if err := g.db.updateForbidden(forbidden, groupNo); err != nil {
+		g.Error("更新全员禁言失败", zap.Error(err))
+		c.ResponseError(errors.New("更新全员禁言失败"))
+		return
+	}
+	if err := g.syncForbiddenWhitelist(groupNo); err != nil {
+		c.ResponseError(err)
+		return
+	}
+	g.sendMemberUpdateCMD(groupNo, req.LoginUID, nil)
+	c.ResponseOK()
+}
+
+// 禁言或解除禁言某个成员
+func (g *Group) memberForbidden(c *wkhttp.Context) {
+	groupNo := c.Param("group_no")
+	var req memberForbiddenReq
+	if err := c.BindJSON(&req); err != nil {
+		c.ResponseError(errors.New("请求数据格式有误！"))
+		return
+	}
+	if err := req.check(); err != nil {
+		c.ResponseError(err)
+		return
+	}
+	operator, err := g.checkGroupManager(groupNo, req.LoginUID)
+	if err != nil {
+		c.ResponseError(err)
+		return
+	}
+	member, err := g.memberDB.query(groupNo, req.MemberUID)
+	if err != nil {
+		g.Error("查询群成员错误", zap.Error(err))
+		c.ResponseError(errors.New("查询群成员错误"))
+		return
+	}
+	if member == nil {
+		c.ResponseError(errors.New("群成员不存在！"))
+		return
+	}
+	if member.Role == MemberRoleCreator || (member.Role == MemberRoleManager && operator.Role != MemberRoleCreator) {
+		c.ResponseError(errors.New("没有权限禁言该成员！"))
+		return
+	}
+	blacklistReq := &config.ChannelBlacklistReq{
+		ChannelReq: config.ChannelReq{
+			ChannelID:   groupNo,
+			ChannelType: common.ChannelTypeGroup.Uint8(),
+		},
+		UIDs: []string{req.MemberUID},
+	}
+	if req.Action == 1 {
+		var expiredAt int64
+		if req.Duration > 0 {
+			expiredAt = time.Now().Unix() + req.Duration
+		}
+		err = g.listDB.upsertBlacklist(groupNo, req.MemberUID, expiredAt)
+		if err != nil {
+			g.Error("禁言成员失败", zap.Error(err))
+			c.ResponseError(errors.New("禁言成员失败"))
+			return
+		}
+		err = base.IM().BlacklistAdd(blacklistReq)
+	} else {
+		err = g.listDB.deleteWithUIDs(tableBlacklist, groupNo, []string{req.MemberUID})
+		if err != nil {
+			g.Error("解除禁言失败", zap.Error(err))
+			c.ResponseError(errors.New("解除禁言失败"))
+			return
+		}
+		err = base.IM().BlacklistRemove(blacklistReq)
+	}
+	if err != nil {
+		g.Error("同步IM黑名单错误", zap.Error(err))
+		c.ResponseError(errors.New("同步IM黑名单错误"))
+		return
+	}
+	g.sendMemberUpdateCMD(groupNo, req.LoginUID, nil)
+	c.ResponseOK()
+}
+
+// 同步全员禁言的白名单，开启全员禁言时白名单为群主和管理员，关闭时清空白名单
+func (g *Group) syncForbiddenWhitelist(groupNo string) error {
+	model, err := g.db.query(groupNo)
+	if err != nil {
+		g.Error("查询群资料错误", zap.Error(err))
+		return errors.New("查询群资料错误")
+	}
+	if model == nil {
+		return errors.New("群不存在！")
+	}
+	uids := make([]string, 0)
+	if model.Forbidden == 1 {
+		uids, err = g.memberDB.queryManagerUIDs(groupNo)
+		if err != nil {
+			g.Error("查询群管理员错误", zap.Error(err))
+			return errors.New("查询群管理员错误")
+		}
+	}
+	if err = g.listDB.resetWhitelist(groupNo, uids); err != nil {
+		g.Error("重置群白名单失败", zap.Error(err))
+		return errors.New("重置群白名单失败")
+	}
+	err = base.IM().WhitelistSet(&config.ChannelWhitelistReq{
+		ChannelReq: config.ChannelReq{
+			ChannelID:   groupNo,
+			ChannelType: common.ChannelTypeGroup.Uint8(),
+		},
+		UIDs: uids,
+	})
+	if err != nil {
+		g.Error("同步IM白名单错误", zap.Error(err))
+		return errors.New("同步IM白名单错误")
+	}
+	return nil
+}
+
+// 解除已到期的成员禁言（定时任务）
+func (g *Group) expireMemberForbidden() {
+	now := time.Now().Unix()
+	models, err := g.listDB.queryExpiredBlacklist(now, 1000)
+	if err != nil {
+		g.Error("查询到期的禁言错误", zap.Error(err))
+		return
+	}
+	groupUIDs := make(map[string][]string)
+	for _, model := range models {
+		groupUIDs[model.GroupNo] = append(groupUIDs[model.GroupNo], model.UID)
+	}
+	for groupNo, uids := range groupUIDs {
+		// 先解除IM的禁言，成功后再删除数据，失败时下次定时任务重试
+		err = base.IM().BlacklistRemove(&config.ChannelBlacklistReq{
+			ChannelReq: config.ChannelReq{
+				ChannelID:   groupNo,
+				ChannelType: common.ChannelTypeGroup.Uint8(),
+			},
+			UIDs: uids,
+		})
+		if err != nil {
+			g.Error("同步IM黑名单错误", zap.Error(err), zap.String("groupNo", groupNo))
+			continue
+		}
+		if err = g.listDB.deleteExpiredBlacklist(groupNo, uids, now); err != nil {
+			g.Error("删除到期的禁言失败", zap.Error(err), zap.String("groupNo", groupNo))
+			continue
+		}
+		g.sendMemberUpdateCMD(groupNo, "", nil)
+	}
+}
+
+type memberForbiddenReq struct {
+	LoginUID  string `json:"login_uid"`
+	MemberUID string `json:"member_uid"` // 被禁言的成员
+	Action    int    `json:"action"`     // 1.禁言 0.解除禁言
+	Duration  int64  `json:"duration"`   // 禁言时长（秒），0表示一直禁言直到手动解除
+}
+
+func (m memberForbiddenReq) check() error {
+	if strings.TrimSpace(m.LoginUID) == "" {
+		return errors.New("登录用户ID不能为空")
+	}
+	if strings.TrimSpace(m.MemberUID) == "" {
+		return errors.New("成员uid不能为空")
+	}
+	if m.Action != 0 && m.Action != 1 {
+		return errors.New("action只能为0或1")
+	}
+	if m.Duration < 0 {
+		return errors.New("禁言时长不能小于0")
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package group
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/util"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/wkhttp"
+	"go.uber.org/zap"
+)
+
+// 转让群主
+func (g *Group) transferCreator(c *wkhttp.Context) {
+	groupNo := c.Param("group_no")
+	toUID := c.Param("to_uid")
+	var req struct {
+		LoginUID string `json:"login_uid"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.ResponseError(errors.New("请求数据格式有误！"))
+		return
+	}
+	if strings.TrimSpace(req.LoginUID) == "" {
+		c.ResponseError(errors.New("登录用户ID不能为空"))
+		return
+	}
+	if toUID == req.LoginUID {
+		c.ResponseError(errors.New("不能转让给自己！"))
+		return
+	}
+	if err := g.checkGroupCreator(groupNo, req.LoginUID); err != nil {
+		c.ResponseError(err)
+		return
+	}
+	isMember, err := g.memberDB.exist(groupNo, toUID)
+	if err != nil {
+		g.Error("查询群成员错误", zap.Error(err))
+		c.ResponseError(errors.New("查询群成员错误"))
+		return
+	}
+	if !isMember {
+		c.ResponseError(errors.New("被转让的用户不是群成员！"))
+		return
+	}
+	if err = g.memberDB.transferCreator(groupNo, req.LoginUID, toUID); err != nil {
+		g.Error("转让群主失败", zap.Error(err))
+		c.ResponseError(errors.New("转让群主失败"))
+		return
+	}
+	// 全员禁言时需要同步新的发言白名单
+	if err = g.syncForbiddenWhitelist(groupNo); err != nil {
+		c.ResponseError(err)
+		return
+	}
+	g.sendMemberUpdateCMD(groupNo, req.LoginUID, nil)
+	c.ResponseOK()
+}
+
+// 添加管理员
+func (g *Group) managerAdd(c *wkhttp.Context) {
+	g.updateMembersRole(c, MemberRoleManager)
+}
+
+// 移除管理员
+func (g *Group) managerRemove(c *wkhttp.Context) {
+	g.updateMembersRole(c, MemberRoleCommon)
+}
+
+// 修改成员角色（只有群主可以设置管理员）
+func (g *Group) updateMembersRole(c *wkhttp.Context, role int) {
+	groupNo := c.Param("group_no")
+	var req memberChangeReq
+	if err := c.BindJSON(&req); err != nil {
+		c.ResponseError(errors.New("请求数据格式有误！"))
+		return
+	}
+	if err := req.check(); err != nil {
+		c.ResponseError(err)
+		return
+	}
+	if err := g.checkGroupCreator(groupNo, req.LoginUID); err != nil {
+		c.ResponseError(err)
+		return
+	}
+	members := util.RemoveRepeatedElement(req.Members)
+	for _, uid := range members {
+		if uid == req.LoginUID {
+			c.ResponseError(errors.New("不能修改群主的角色！"))
+			return
+		}
+	}
+	existUIDs, err := g.memberDB.queryExistUIDs(groupNo, members)
+	if err != nil {
+		g.Error("查询群成员错误", zap.Error(err))
+		c.ResponseError(errors.New("查询群成员错误"))
+		return
+	}
+	if len(existUIDs) != len(members) {
+		c.ResponseError(errors.New("存在不是群成员的用户！"))
+		return
+	}
+	if err = g.memberDB.updateRole(groupNo, members, role); err != nil {
+		g.Error("修改成员角色失败", zap.Error(err))
+		c.ResponseError(errors.New("修改成员角色失败"))
+		return
+	}
+	// 全员禁言时需要同步新的发言白名单
+	if err = g.syncForbiddenWhitelist(groupNo); err != nil {
+		c.ResponseError(err)
+		return
+	}
+	g.sendMemberUpdateCMD(groupNo, req.LoginUID, nil)
+	c.ResponseOK()
+}
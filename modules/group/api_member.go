@@ -0,0 +1,365 @@
+package group
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/common"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/util"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/wkhttp"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/base"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/pkg/imclient"
+	"go.uber.org/zap"
+)
+
+// 邀请成员进群
+func (g *Group) memberAdd(c *wkhttp.Context) {
+	groupNo := c.Param("group_no")
+	var req memberChangeReq
+	if err := c.BindJSON(&req); err != nil {
+		c.ResponseError(errors.New("请求数据格式有误！"))
+		return
+	}
+	if err := req.check(); err != nil {
+		c.ResponseError(err)
+		return
+	}
+	if _, err := g.checkGroupMember(groupNo, req.LoginUID); err != nil {
+		c.ResponseError(err)
+		return
+	}
+	existUIDs, err := g.memberDB.queryExistUIDs(groupNo, req.Members)
+	if err != nil {
+		g.Error("查询群成员错误", zap.Error(err))
+		c.ResponseError(errors.New("查询群成员错误"))
+		return
+	}
+	existMap := make(map[string]bool, len(existUIDs))
+	for _, uid := range existUIDs {
+		existMap[uid] = true
+	}
+	newMembers := make([]string, 0, len(req.Members))
+	for _, uid := range util.RemoveRepeatedElement(req.Members) {
+		if strings.TrimSpace(uid) == "" || existMap[uid] {
+			continue
+		}
+		newMembers = append(newMembers, uid)
+	}
+	if len(newMembers) == 0 {
+		c.ResponseOK()
+		return
+	}
+	err = g.memberDB.insertIgnoreBatch(groupNo, newMembers, req.LoginUID)
+	if err != nil {
+		g.Error("添加群成员失败", zap.Error(err))
+		c.ResponseError(errors.New("添加群成员失败"))
+		return
+	}
+	err = base.IM().AddSubscribers(&config.SubscriberAddReq{
+		ChannelID:   groupNo,
+		ChannelType: common.ChannelTypeGroup.Uint8(),
+		Reset:       0,
+		Subscribers: newMembers,
+	})
+	if err != nil {
+		g.Error("添加订阅错误", zap.Error(err))
+		c.ResponseError(errors.New("添加订阅错误"))
+		return
+	}
+	g.sendMemberUpdateCMD(groupNo, req.LoginUID, nil)
+	c.ResponseOK()
+}
+
+// 移除群成员
+func (g *Group) memberRemove(c *wkhttp.Context) {
+	groupNo := c.Param("group_no")
+	var req memberChangeReq
+	if err := c.BindJSON(&req); err != nil {
+		c.ResponseError(errors.New("请求数据格式有误！"))
+		return
+	}
+	if err := req.check(); err != nil {
+		c.ResponseError(err)
+		return
+	}
+	operator, err := g.checkGroupManager(groupNo, req.LoginUID)
+	if err != nil {
+		c.ResponseError(err)
+		return
+	}
+	for _, uid := range req.Members {
+		if uid == req.LoginUID {
+			c.ResponseError(errors.New("不能移除自己！"))
+			return
+		}
+	}
+	if operator.Role != MemberRoleCreator {
+		// 管理员只能移除普通成员
+		for _, uid := range req.Members {
+			member, err := g.memberDB.query(groupNo, uid)
+			if err != nil {
+				g.Error("查询群成员错误", zap.Error(err))
+				c.ResponseError(errors.New("查询群成员错误"))
+				return
+			}
+			if member != nil && member.Role != MemberRoleCommon {
+				c.ResponseError(errors.New("管理员不能移除群主或其他管理员！"))
+				return
+			}
+		}
+	}
+	err = g.removeMembers(groupNo, req.LoginUID, req.Members)
+	if err != nil {
+		c.ResponseError(err)
+		return
+	}
+	c.ResponseOK()
+}
+
+// 退出群聊
+func (g *Group) memberExit(c *wkhttp.Context) {
+	groupNo := c.Param("group_no")
+	var req struct {
+		LoginUID string `json:"login_uid"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.ResponseError(errors.New("请求数据格式有误！"))
+		return
+	}
+	if strings.TrimSpace(req.LoginUID) == "" {
+		c.ResponseError(errors.New("登录用户ID不能为空"))
+		return
+	}
+	member, err := g.checkGroupMember(groupNo, req.LoginUID)
+	if err != nil {
+		c.ResponseError(err)
+		return
+	}
+	if member.Role == MemberRoleCreator {
+		c.ResponseError(errors.New("群主不能退出群聊，请先转让群主！"))
+		return
+	}
+	err = g.removeMembers(groupNo, req.LoginUID, []string{req.LoginUID})
+	if err != nil {
+		c.ResponseError(err)
+		return
+	}
+	c.ResponseOK()
+}
+
+// 群成员列表
+func (g *Group) memberList(c *wkhttp.Context) {
+	groupNo := c.Param("group_no")
+	pageIndex, pageSize := c.GetPage()
+	models, err := g.memberDB.queryWithPage(groupNo, uint64(pageIndex), uint64(pageSize))
+	if err != nil {
+		g.Error("查询群成员列表错误", zap.Error(err))
+		c.ResponseError(errors.New("查询群成员列表错误"))
+		return
+	}
+	total, err := g.memberDB.queryCount(groupNo)
+	if err != nil {
+		g.Error("查询群成员数量错误", zap.Error(err))
+		c.ResponseError(errors.New("查询群成员数量错误"))
+		return
+	}
+	resps := make([]*memberResp, 0, len(models))
+	for _, model := range models {
+		resps = append(resps, newMemberResp(model))
+	}
+	c.Response(common.NewPageResult(pageIndex, pageSize, total, resps))
+}
+
+// 获取某个群成员
+func (g *Group) memberGet(c *wkhttp.Context) {
+	groupNo := c.Param("group_no")
+	uid := c.Param("uid")
+	model, err := g.memberDB.queryWithUID(groupNo, uid)
+	if err != nil {
+		g.Error("查询群成员错误", zap.Error(err))
+		c.ResponseError(errors.New("查询群成员错误"))
+		return
+	}
+	if model == nil {
+		c.ResponseError(errors.New("群成员不存在！"))
+		return
+	}
+	c.Response(newMemberResp(model))
+}
+
+// 移除群成员并同步到IM
+func (g *Group) removeMembers(groupNo string, operator string, uids []string) error {
+	err := g.memberDB.deleteWithUIDs(groupNo, uids)
+	if err != nil {
+		g.Error("移除群成员失败", zap.Error(err))
+		return errors.New("移除群成员失败")
+	}
+	err = base.IM().RemoveSubscribers(&config.SubscriberRemoveReq{
+		ChannelID:   groupNo,
+		ChannelType: common.ChannelTypeGroup.Uint8(),
+		Subscribers: uids,
+	})
+	if err != nil {
+		g.Error("移除订阅者错误", zap.Error(err))
+		return errors.New("移除订阅者错误")
+	}
+	g.sendMemberUpdateCMD(groupNo, operator, uids)
+	return nil
+}
+
+// 查询群成员，旧群不在群成员表内的成员从悟空IM确认后补录
+func (g *Group) queryMemberWithLegacy(groupNo string, uid string) (*memberModel, error) {
+	member, err := g.memberDB.query(groupNo, uid)
+	if err != nil || member != nil {
+		return member, err
+	}
+	model, err := g.db.query(groupNo)
+	if err != nil {
+		return nil, err
+	}
+	if model == nil || model.Legacy != 1 {
+		return nil, nil
+	}
+	// 悟空IM没有查询订阅者的接口，用户的最近会话内有此群即为群订阅者
+	conversations, err := base.IM().SyncConversations(&imclient.SyncConversationsReq{
+		UID:      uid,
+		MsgCount: 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, conversation := range conversations {
+		if conversation.ChannelID != groupNo || conversation.ChannelType != common.ChannelTypeGroup.Uint8() {
+			continue
+		}
+		err = g.memberDB.insertIgnore(&memberModel{
+			GroupNo: groupNo,
+			UID:     uid,
+			Role:    MemberRoleCommon,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return g.memberDB.query(groupNo, uid)
+	}
+	return nil, nil
+}
+
+// 判断用户是否是群成员
+func (g *Group) checkGroupMember(groupNo string, uid string) (*memberModel, error) {
+	member, err := g.queryMemberWithLegacy(groupNo, uid)
+	if err != nil {
+		g.Error("查询群成员错误", zap.Error(err))
+		return nil, errors.New("查询群成员错误")
+	}
+	if member == nil {
+		return nil, errors.New("不是群成员，无权操作！")
+	}
+	return member, nil
+}
+
+// 判断用户是否是群主或管理员
+func (g *Group) checkGroupManager(groupNo string, uid string) (*memberModel, error) {
+	member, err := g.checkGroupMember(groupNo, uid)
+	if err != nil {
+		return nil, err
+	}
+	if member.Role != MemberRoleCreator && member.Role != MemberRoleManager {
+		return nil, errors.New("只有群主或管理员才能操作！")
+	}
+	return member, nil
+}
+
+// 判断用户是否是群主
+func (g *Group) checkGroupCreator(groupNo string, uid string) error {
+	member, err := g.checkGroupMember(groupNo, uid)
+	if err != nil {
+		return err
+	}
+	if member.Role != MemberRoleCreator {
+		return errors.New("只有群主才能操作！")
+	}
+	return nil
+}
+
+// 发送群成员变更的cmd，removed为被移除的成员（已经不在群订阅者内，需要单独通知）
+func (g *Group) sendMemberUpdateCMD(groupNo string, operator string, removed []string) {
+	param := map[string]interface{}{
+		"group_no": groupNo,
+	}
+	err := base.SendCMD(config.MsgCMDReq{
+		ChannelID:   groupNo,
+		ChannelType: common.ChannelTypeGroup.Uint8(),
+		FromUID:     operator,
+		CMD:         common.CMDGroupMemberUpdate,
+		Param:       param,
+	})
+	if err != nil {
+		g.Error("发送群成员更新cmd错误", zap.Error(err))
+	}
+	if len(removed) > 0 {
+		err = base.SendCMD(config.MsgCMDReq{
+			ChannelID:   groupNo,
+			ChannelType: common.ChannelTypeGroup.Uint8(),
+			FromUID:     operator,
+			Subscribers: removed,
+			CMD:         common.CMDGroupMemberUpdate,
+			Param:       param,
+		})
+		if err != nil {
+			g.Error("发送被移除成员的cmd错误", zap.Error(err))
+		}
+	}
+	err = base.SendCMD(config.MsgCMDReq{
+		ChannelID:   groupNo,
+		ChannelType: common.ChannelTypeGroup.Uint8(),
+		FromUID:     operator,
+		CMD:         common.CMDChannelUpdate,
+		Param: map[string]interface{}{
+			"channel_id":   groupNo,
+			"channel_type": common.ChannelTypeGroup.Uint8(),
+		},
+	})
+	if err != nil {
+		g.Error("发送更新群资料cmd错误", zap.Error(err))
+	}
+}
+
+type memberChangeReq struct {
+	LoginUID string   `json:"login_uid"`
+	Members  []string `json:"members"` // 成员uid集合
+}
+
+func (m memberChangeReq) check() error {
+	if strings.TrimSpace(m.LoginUID) == "" {
+		return errors.New("登录用户ID不能为空")
+	}
+	if len(m.Members) == 0 {
+		return errors.New("成员不能为空")
+	}
+	return nil
+}
+
+type memberResp struct {
+	GroupNo   string `json:"group_no"`
+	UID       string `json:"uid"`
+	Name      string `json:"name"`
+	Avatar    string `json:"avatar"`
+	Inviter   string `json:"inviter,omitempty"`
+	Role      int    `json:"role"` // 成员角色 0.普通成员 1.群主 2.管理员
+	CreatedAt string `json:"created_at"`
+}
+
+func newMemberResp(m *memberDetailModel) *memberResp {
+	return &memberResp{
+		GroupNo:   m.GroupNo,
+		UID:       m.UID,
+		Name:      m.Name,
+		Avatar:    fmt.Sprintf("users/%s/avatar", m.UID),
+		Inviter:   m.Inviter,
+		Role:      m.Role,
+		CreatedAt: m.CreatedAt.String(),
+	}
+}
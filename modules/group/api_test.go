@@ -3,13 +3,18 @@ package group
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/register"
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/util"
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/testutil"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/base"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -31,6 +36,56 @@ func TestCreateGroup(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 }
 
+func TestCreateExistGroupWithoutMember(t *testing.T) {
+	s, ctx := testutil.NewTestServer()
+	g := New(ctx)
+	//清除数据
+	err := testutil.CleanAllTables(ctx)
+	assert.NoError(t, err)
+	err = g.db.insert(&GroupModel{GroupNo: "g1", Name: "group1", Creator: "1"})
+	assert.NoError(t, err)
+	err = g.memberDB.insertIgnore(&memberModel{GroupNo: "g1", UID: "1", Role: MemberRoleCreator})
+	assert.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/group/create", bytes.NewReader([]byte(util.ToJson(map[string]interface{}{
+		"group_no":  "g1",
+		"login_uid": "2",
+	}))))
+	s.GetRoute().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	member, err := g.memberDB.query("g1", "2")
+	assert.NoError(t, err)
+	assert.Nil(t, member)
+}
+
+func TestCreateGroupWithoutMembers(t *testing.T) {
+	s, ctx := testutil.NewTestServer()
+	g := New(ctx)
+	//清除数据
+	err := testutil.CleanAllTables(ctx)
+	assert.NoError(t, err)
+	// 群已存在但没有成员
+	err = g.db.insert(&GroupModel{GroupNo: "g1", Name: "group1"})
+	assert.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/group/create", bytes.NewReader([]byte(util.ToJson(map[string]interface{}{
+		"group_no":  "g1",
+		"login_uid": "2",
+	}))))
+	s.GetRoute().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	member, err := g.memberDB.query("g1", "2")
+	assert.NoError(t, err)
+	assert.Equal(t, MemberRoleCreator, member.Role)
+	model, err := g.db.query("g1")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", model.Creator)
+}
+
 func TestGetGroup(t *testing.T) {
 	s, ctx := testutil.NewTestServer()
 	g := New(ctx)
@@ -50,4 +105,190 @@ func TestGetGroup(t *testing.T) {
 	s.GetRoute().ServeHTTP(w, req)
 	fmt.Println(w.Body.String())
 	assert.Equal(t, true, strings.Contains(w.Body.String(), `"group_no":`))
+
+	// 未创建的群不保存
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/v1/groups/g2", nil)
+	s.GetRoute().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	model, err := g.db.query("g2")
+	assert.NoError(t, err)
+	assert.Nil(t, model)
+}
+
+func TestDatasourceSubscribers(t *testing.T) {
+	_, ctx := testutil.NewTestServer()
+	g := New(ctx)
+	//清除数据
+	err := testutil.CleanAllTables(ctx)
+	assert.NoError(t, err)
+	err = g.db.insert(&GroupModel{GroupNo: "g1", Name: "group1", Creator: "1"})
+	assert.NoError(t, err)
+	err = g.memberDB.insertIgnore(&memberModel{GroupNo: "g1", UID: "1"})
+	assert.NoError(t, err)
+	err = g.memberDB.insertIgnore(&memberModel{GroupNo: "g1", UID: "2"})
+	assert.NoError(t, err)
+
+	uids, err := g.datasourceSubscribers("g1")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"1", "2"}, uids)
+
+	// 旧群由悟空IM使用自己的订阅者
+	err = g.db.insert(&GroupModel{GroupNo: "g2", Name: "group2", Creator: "1", Legacy: 1})
+	assert.NoError(t, err)
+	_, err = g.datasourceSubscribers("g2")
+	assert.ErrorIs(t, err, register.ErrDatasourceNotProcess)
+}
+
+func TestLegacyGroupMember(t *testing.T) {
+	s, ctx := testutil.NewTestServer()
+	g := New(ctx)
+	//清除数据
+	err := testutil.CleanAllTables(ctx)
+	assert.NoError(t, err)
+	// 模拟悟空IM，用户2的最近会话内有旧群g1
+	im := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/conversation/sync" {
+			body, _ := io.ReadAll(r.Body)
+			if strings.Contains(string(body), `"uid":"2"`) {
+				w.Write([]byte(`[{"channel_id":"g1","channel_type":2}]`))
+				return
+			}
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer im.Close()
+	cfg := config.New()
+	cfg.WuKongIM.APIURL = im.URL
+	base.SetupIM(cfg)
+	defer base.SetupIM(ctx.GetConfig())
+
+	err = g.db.insert(&GroupModel{GroupNo: "g1", Name: "group1", Legacy: 1})
+	assert.NoError(t, err)
+
+	invite := func(loginUID string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/groups/g1/members", bytes.NewReader([]byte(util.ToJson(map[string]interface{}{
+			"login_uid": loginUID,
+			"members":   []string{"3"},
+		}))))
+		s.GetRoute().ServeHTTP(w, req)
+		return w.Code
+	}
+	// 用户4不是旧群的订阅者
+	assert.Equal(t, http.StatusBadRequest, invite("4"))
+	// 用户2是旧群的订阅者，补录为群成员
+	assert.Equal(t, http.StatusOK, invite("2"))
+	member, err := g.memberDB.query("g1", "2")
+	assert.NoError(t, err)
+	assert.Equal(t, MemberRoleCommon, member.Role)
+}
+
+func TestMemberList(t *testing.T) {
+	s, ctx := testutil.NewTestServer()
+	g := New(ctx)
+	//清除数据
+	err := testutil.CleanAllTables(ctx)
+	assert.NoError(t, err)
+	err = g.memberDB.insertIgnoreBatch("g1", []string{"1", "2"}, "1")
+	assert.NoError(t, err)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/groups/g1/members?page_index=1&page_size=10", nil)
+
+	s.GetRoute().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, true, strings.Contains(w.Body.String(), `"total":2`))
+}
+
+func TestUpdateNameWithoutPermission(t *testing.T) {
+	s, ctx := testutil.NewTestServer()
+	g := New(ctx)
+	//清除数据
+	err := testutil.CleanAllTables(ctx)
+	assert.NoError(t, err)
+	err = g.memberDB.insertIgnore(&memberModel{GroupNo: "g1", UID: "1", Role: MemberRoleCreator})
+	assert.NoError(t, err)
+	err = g.memberDB.insertIgnore(&memberModel{GroupNo: "g1", UID: "2"})
+	assert.NoError(t, err)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/v1/groups/g1", bytes.NewReader([]byte(util.ToJson(map[string]interface{}{
+		"name":      "newname",
+		"login_uid": "2",
+	}))))
+
+	s.GetRoute().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestDatasourceBlacklistExpired(t *testing.T) {
+	_, ctx := testutil.NewTestServer()
+	g := New(ctx)
+	//清除数据
+	err := testutil.CleanAllTables(ctx)
+	assert.NoError(t, err)
+	err = g.listDB.upsertBlacklist("g1", "1", 0)
+	assert.NoError(t, err)
+	err = g.listDB.upsertBlacklist("g1", "2", time.Now().Unix()-10)
+	assert.NoError(t, err)
+
+	uids, err := g.datasourceBlacklist("g1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1"}, uids)
+}
+
+func TestForbidden(t *testing.T) {
+	s, ctx := testutil.NewTestServer()
+	g := New(ctx)
+	//清除数据
+	err := testutil.CleanAllTables(ctx)
+	assert.NoError(t, err)
+	err = g.db.insert(&GroupModel{GroupNo: "g1", Name: "group1", Creator: "1"})
+	assert.NoError(t, err)
+	err = g.memberDB.insertIgnore(&memberModel{GroupNo: "g1", UID: "1", Role: MemberRoleCreator})
+	assert.NoError(t, err)
+	err = g.memberDB.insertIgnore(&memberModel{GroupNo: "g1", UID: "2", Role: MemberRoleCommon})
+	assert.NoError(t, err)
+
+	// 普通成员不能开启全员禁言
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/v1/groups/g1/forbidden/1", bytes.NewReader([]byte(util.ToJson(map[string]interface{}{
+		"login_uid": "2",
+	}))))
+	s.GetRoute().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("PUT", "/v1/groups/g1/forbidden/1", bytes.NewReader([]byte(util.ToJson(map[string]interface{}{
+		"login_uid": "1",
+	}))))
+	s.GetRoute().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	model, err := g.db.query("g1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, model.Forbidden)
+
+	// 普通成员不能禁言群主
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/v1/groups/g1/forbidden_with_member", bytes.NewReader([]byte(util.ToJson(map[string]interface{}{
+		"login_uid":  "2",
+		"member_uid": "1",
+		"action":     1,
+	}))))
+	s.GetRoute().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/v1/groups/g1/forbidden_with_member", bytes.NewReader([]byte(util.ToJson(map[string]interface{}{
+		"login_uid":  "1",
+		"member_uid": "2",
+		"action":     1,
+		"duration":   60,
+	}))))
+	s.GetRoute().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	uids, err := g.datasourceBlacklist("g1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2"}, uids)
 }
@@ -0,0 +1,47 @@
+package group
+
+import (
+	"time"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/register"
+)
+
+// 提供给悟空IM的群数据源
+
+// 群资料
+func (g *Group) datasourceChannelInfo(groupNo string) (map[string]interface{}, error) {
+	model, err := g.db.query(groupNo)
+	if err != nil {
+		return nil, err
+	}
+	if model == nil {
+		return nil, nil
+	}
+	return map[string]interface{}{
+		"large": 0,
+		"ban":   0,
+	}, nil
+}
+
+// 群订阅者（群成员），旧群的成员只在悟空IM的订阅者内，不处理
+// 旧群的成员变更（邀请、移除、退出）都会同步到悟空IM的订阅者，所以旧群一直由悟空IM维护订阅者
+func (g *Group) datasourceSubscribers(groupNo string) ([]string, error) {
+	model, err := g.db.query(groupNo)
+	if err != nil {
+		return nil, err
+	}
+	if model == nil || model.Legacy == 1 {
+		return nil, register.ErrDatasourceNotProcess
+	}
+	return g.memberDB.queryUIDs(groupNo)
+}
+
+// 群黑名单
+func (g *Group) datasourceBlacklist(groupNo string) ([]string, error) {
+	return g.listDB.queryValidBlacklistUIDs(groupNo, time.Now().Unix())
+}
+
+// 群白名单
+func (g *Group) datasourceWhitelist(groupNo string) ([]string, error) {
+	return g.listDB.queryUIDs(tableWhitelist, groupNo)
+}
@@ -35,8 +35,22 @@ func (db *DB) updateName(name string, groupNo string) error {
 	return err
 }
 
+// 更新群主
+func (db *DB) updateCreator(creator string, groupNo string) error {
+	_, err := db.session.Update("group").Set("creator", creator).Where("group_no=?", groupNo).Exec()
+	return err
+}
+
+// 更新全员禁言状态
+func (db *DB) updateForbidden(forbidden int, groupNo string) error {
+	_, err := db.session.Update("group").Set("forbidden", forbidden).Where("group_no=?", groupNo).Exec()
+	return err
+}
+
 type GroupModel struct {
-	GroupNo string
-	Name    string
-	Creator string
+	GroupNo   string
+	Name      string
+	Creator   string
+	Forbidden int // 是否全员禁言
+	Legacy    int // 是否是群成员表之前创建的群（成员只在悟空IM的订阅者内）
 }
@@ -0,0 +1,100 @@
+package group
+
+import (
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/db"
+	"github.com/gocraft/dbr/v2"
+)
+
+const (
+	tableBlacklist = "group_blacklist" // 群黑名单
+	tableWhitelist = "group_whitelist" // 群白名单
+)
+
+// listDB 群黑白名单
+type listDB struct {
+	ctx     *config.Context
+	session *dbr.Session
+}
+
+func newListDB(ctx *config.Context) *listDB {
+	return &listDB{
+		ctx:     ctx,
+		session: ctx.DB(),
+	}
+}
+
+// 查询名单内的所有uid
+func (l *listDB) queryUIDs(table string, groupNo string) ([]string, error) {
+	var uids []string
+	_, err := l.session.Select("uid").From(table).Where("group_no=?", groupNo).Load(&uids)
+	return uids, err
+}
+
+// 删除名单内指定uid
+func (l *listDB) deleteWithUIDs(table string, groupNo string, uids []string) error {
+	if len(uids) == 0 {
+		return nil
+	}
+	_, err := l.session.DeleteFrom(table).Where("group_no=? and uid in ?", groupNo, uids).Exec()
+	return err
+}
+
+// 重置白名单
+func (l *listDB) resetWhitelist(groupNo string, uids []string) error {
+	tx, err := l.session.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.RollbackUnlessCommitted()
+	_, err = tx.DeleteFrom(tableWhitelist).Where("group_no=?", groupNo).Exec()
+	if err != nil {
+		return err
+	}
+	if len(uids) > 0 {
+		builder := tx.InsertInto(tableWhitelist).Columns("group_no", "uid")
+		for _, uid := range uids {
+			builder = builder.Values(groupNo, uid)
+		}
+		if _, err = builder.Exec(); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// 添加或更新黑名单（禁言），expiredAt为0表示不过期
+func (l *listDB) upsertBlacklist(groupNo string, uid string, expiredAt int64) error {
+	_, err := l.session.InsertBySql("insert into group_blacklist(group_no,uid,expired_at) values(?,?,?) ON DUPLICATE KEY UPDATE expired_at=VALUES(expired_at)", groupNo, uid, expiredAt).Exec()
+	return err
+}
+
+// 查询未过期的黑名单uid
+func (l *listDB) queryValidBlacklistUIDs(groupNo string, now int64) ([]string, error) {
+	var uids []string
+	_, err := l.session.Select("uid").From(tableBlacklist).Where("group_no=? and (expired_at=0 or expired_at>?)", groupNo, now).Load(&uids)
+	return uids, err
+}
+
+// 查询已过期的黑名单
+func (l *listDB) queryExpiredBlacklist(now int64, limit uint64) ([]*listModel, error) {
+	var models []*listModel
+	_, err := l.session.Select("*").From(tableBlacklist).Where("expired_at>0 and expired_at<=?", now).OrderAsc("expired_at").Limit(limit).Load(&models)
+	return models, err
+}
+
+// 删除已过期的黑名单
+func (l *listDB) deleteExpiredBlacklist(groupNo string, uids []string, now int64) error {
+	if len(uids) == 0 {
+		return nil
+	}
+	_, err := l.session.DeleteFrom(tableBlacklist).Where("group_no=? and uid in ? and expired_at>0 and expired_at<=?", groupNo, uids, now).Exec()
+	return err
+}
+
+type listModel struct {
+	GroupNo   string
+	UID       string
+	ExpiredAt int64 // 过期时间（unix秒），只有黑名单有效
+	db.BaseModel
+}
@@ -0,0 +1,177 @@
+package group
+
+import (
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/db"
+	"github.com/gocraft/dbr/v2"
+)
+
+const (
+	// MemberRoleCommon 普通成员
+	MemberRoleCommon = 0
+	// MemberRoleCreator 群主
+	MemberRoleCreator = 1
+	// MemberRoleManager 管理员
+	MemberRoleManager = 2
+)
+
+type memberDB struct {
+	ctx     *config.Context
+	session *dbr.Session
+}
+
+func newMemberDB(ctx *config.Context) *memberDB {
+	return &memberDB{
+		ctx:     ctx,
+		session: ctx.DB(),
+	}
+}
+
+// 添加成员（已存在则忽略）
+func (m *memberDB) insertIgnore(md *memberModel) error {
+	_, err := m.session.InsertInto("group_member").Ignore().Columns("group_no", "uid", "inviter", "role").Values(md.GroupNo, md.UID, md.Inviter, md.Role).Exec()
+	return err
+}
+
+// 批量添加成员（已存在则忽略）
+func (m *memberDB) insertIgnoreBatch(groupNo string, uids []string, inviter string) error {
+	if len(uids) == 0 {
+		return nil
+	}
+	builder := m.session.InsertInto("group_member").Ignore().Columns("group_no", "uid", "inviter")
+	for _, uid := range uids {
+		builder = builder.Values(groupNo, uid, inviter)
+	}
+	_, err := builder.Exec()
+	return err
+}
+
+// 删除群内指定成员
+func (m *memberDB) deleteWithUIDs(groupNo string, uids []string) error {
+	if len(uids) == 0 {
+		return nil
+	}
+	_, err := m.session.DeleteFrom("group_member").Where("group_no=? and uid in ?", groupNo, uids).Exec()
+	return err
+}
+
+// 更新成员角色
+func (m *memberDB) updateRole(groupNo string, uids []string, role int) error {
+	if len(uids) == 0 {
+		return nil
+	}
+	_, err := m.session.Update("group_member").Set("role", role).Where("group_no=? and uid in ?", groupNo, uids).Exec()
+	return err
+}
+
+// 转让群主（原群主变为普通成员）
+func (m *memberDB) transferCreator(groupNo string, fromUID string, toUID string) error {
+	tx, err := m.session.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.RollbackUnlessCommitted()
+	_, err = tx.Update("group_member").Set("role", MemberRoleCommon).Where("group_no=? and uid=?", groupNo, fromUID).Exec()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Update("group_member").Set("role", MemberRoleCreator).Where("group_no=? and uid=?", groupNo, toUID).Exec()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Update("group").Set("creator", toUID).Where("group_no=?", groupNo).Exec()
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// 查询群成员
+func (m *memberDB) query(groupNo string, uid string) (*memberModel, error) {
+	var model *memberModel
+	_, err := m.session.Select("*").From("group_member").Where("group_no=? and uid=?", groupNo, uid).Load(&model)
+	return model, err
+}
+
+// 查询群所有成员uid
+func (m *memberDB) queryUIDs(groupNo string) ([]string, error) {
+	var uids []string
+	_, err := m.session.Select("uid").From("group_member").Where("group_no=?", groupNo).Load(&uids)
+	return uids, err
+}
+
+// 查询用户加入的所有群编号
+func (m *memberDB) queryGroupNosWithUID(uid string) ([]string, error) {
+	var groupNos []string
+	_, err := m.session.Select("group_no").From("group_member").Where("uid=?", uid).Load(&groupNos)
+	return groupNos, err
+}
+
+// 查询与用户同群的成员（不包含用户自己）
+func (m *memberDB) queryPeerUIDs(uid string, limit uint64) ([]string, error) {
+	var uids []string
+	_, err := m.session.Select("distinct uid").From("group_member").Where("group_no in (select group_no from group_member where uid=?) and uid<>?", uid, uid).Limit(limit).Load(&uids)
+	return uids, err
+}
+
+// 查询群主和管理员的uid
+func (m *memberDB) queryManagerUIDs(groupNo string) ([]string, error) {
+	var uids []string
+	_, err := m.session.Select("uid").From("group_member").Where("group_no=? and role in ?", groupNo, []int{MemberRoleCreator, MemberRoleManager}).Load(&uids)
+	return uids, err
+}
+
+// 查询指定uid中已经是群成员的uid
+func (m *memberDB) queryExistUIDs(groupNo string, uids []string) ([]string, error) {
+	if len(uids) == 0 {
+		return nil, nil
+	}
+	var existUIDs []string
+	_, err := m.session.Select("uid").From("group_member").Where("group_no=? and uid in ?", groupNo, uids).Load(&existUIDs)
+	return existUIDs, err
+}
+
+// 查询群成员详情
+func (m *memberDB) queryWithUID(groupNo string, uid string) (*memberDetailModel, error) {
+	var model *memberDetailModel
+	_, err := m.memberDetailSelect().Where("group_member.group_no=? and group_member.uid=?", groupNo, uid).Load(&model)
+	return model, err
+}
+
+// 分页查询群成员
+func (m *memberDB) queryWithPage(groupNo string, pageIndex, pageSize uint64) ([]*memberDetailModel, error) {
+	var models []*memberDetailModel
+	_, err := m.memberDetailSelect().Where("group_member.group_no=?", groupNo).OrderAsc("group_member.id").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Load(&models)
+	return models, err
+}
+
+// 查询群成员数量
+func (m *memberDB) queryCount(groupNo string) (int64, error) {
+	var count int64
+	_, err := m.session.Select("count(*)").From("group_member").Where("group_no=?", groupNo).Load(&count)
+	return count, err
+}
+
+// 是否是群成员
+func (m *memberDB) exist(groupNo string, uid string) (bool, error) {
+	var count int
+	_, err := m.session.Select("count(*)").From("group_member").Where("group_no=? and uid=?", groupNo, uid).Load(&count)
+	return count > 0, err
+}
+
+func (m *memberDB) memberDetailSelect() *dbr.SelectStmt {
+	return m.session.Select("group_member.*,IFNULL(user.name,'') name").From("group_member").LeftJoin("user", "group_member.uid=user.uid")
+}
+
+type memberModel struct {
+	GroupNo string
+	UID     string
+	Inviter string // 邀请者
+	Role    int    // 成员角色
+	db.BaseModel
+}
+
+type memberDetailModel struct {
+	memberModel
+	Name string // 成员名字
+}
@@ -0,0 +1,67 @@
+package group
+
+import (
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+)
+
+// IService 群服务（提供给其他模块使用，其他模块不直接查询群的表）
+type IService interface {
+	// IsManager 是否是群主或管理员
+	IsManager(groupNo string, uid string) (bool, error)
+	// GetGroupNosWithUID 获取用户加入的所有群编号
+	GetGroupNosWithUID(uid string) ([]string, error)
+	// GetGroupName 获取群名，群不存在返回空
+	GetGroupName(groupNo string) (string, error)
+	// GetPeerUIDs 获取与用户同群的成员，最多返回limit个
+	GetPeerUIDs(uid string, limit uint64) ([]string, error)
+}
+
+// Service Service
+type Service struct {
+	ctx      *config.Context
+	db       *DB
+	memberDB *memberDB
+}
+
+// NewService NewService
+func NewService(ctx *config.Context) IService {
+	return &Service{
+		ctx:      ctx,
+		db:       NewDB(ctx),
+		memberDB: newMemberDB(ctx),
+	}
+}
+
+// IsManager 是否是群主或管理员
+func (s *Service) IsManager(groupNo string, uid string) (bool, error) {
+	member, err := s.memberDB.query(groupNo, uid)
+	if err != nil {
+		return false, err
+	}
+	if member == nil {
+		return false, nil
+	}
+	return member.Role == MemberRoleCreator || member.Role == MemberRoleManager, nil
+}
+
+// GetGroupNosWithUID 获取用户加入的所有群编号
+func (s *Service) GetGroupNosWithUID(uid string) ([]string, error) {
+	return s.memberDB.queryGroupNosWithUID(uid)
+}
+
+// GetGroupName 获取群名，群不存在返回空
+func (s *Service) GetGroupName(groupNo string) (string, error) {
+	model, err := s.db.query(groupNo)
+	if err != nil {
+		return "", err
+	}
+	if model == nil {
+		return "", nil
+	}
+	return model.Name, nil
+}
+
+// GetPeerUIDs 获取与用户同群的成员，最多返回limit个
+func (s *Service) GetPeerUIDs(uid string, limit uint64) ([]string, error) {
+	return s.memberDB.queryPeerUIDs(uid, limit)
+}
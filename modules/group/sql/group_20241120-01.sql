@@ -0,0 +1,35 @@
+-- +migrate Up
+
+-- 群成员
+create table `group_member`
+(
+  id         integer     not null primary key AUTO_INCREMENT,
+  group_no   VARCHAR(40) not null default '',                             -- 群唯一编号
+  uid        VARCHAR(40) not null default '',                             -- 成员uid
+  created_at timeStamp     not null DEFAULT CURRENT_TIMESTAMP, -- 创建时间
+  updated_at timeStamp     not null DEFAULT CURRENT_TIMESTAMP  -- 更新时间
+);
+CREATE UNIQUE INDEX group_member_groupNo_uid on `group_member` (group_no, uid);
+CREATE INDEX group_member_uid on `group_member` (uid);
+
+-- 群黑名单（在黑名单内的成员不能在群内发消息）
+create table `group_blacklist`
+(
+  id         integer     not null primary key AUTO_INCREMENT,
+  group_no   VARCHAR(40) not null default '',                             -- 群唯一编号
+  uid        VARCHAR(40) not null default '',                             -- 成员uid
+  created_at timeStamp     not null DEFAULT CURRENT_TIMESTAMP, -- 创建时间
+  updated_at timeStamp     not null DEFAULT CURRENT_TIMESTAMP  -- 更新时间
+);
+CREATE UNIQUE INDEX group_blacklist_groupNo_uid on `group_blacklist` (group_no, uid);
+
+-- 群白名单（白名单不为空时，只有白名单内的成员能在群内发消息）
+create table `group_whitelist`
+(
+  id         integer     not null primary key AUTO_INCREMENT,
+  group_no   VARCHAR(40) not null default '',                             -- 群唯一编号
+  uid        VARCHAR(40) not null default '',                             -- 成员uid
+  created_at timeStamp     not null DEFAULT CURRENT_TIMESTAMP, -- 创建时间
+  updated_at timeStamp     not null DEFAULT CURRENT_TIMESTAMP  -- 更新时间
+);
+CREATE UNIQUE INDEX group_whitelist_groupNo_uid on `group_whitelist` (group_no, uid);
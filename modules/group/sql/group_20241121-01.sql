@@ -0,0 +1,3 @@
+-- +migrate Up
+
+ALTER TABLE `group_member` ADD COLUMN inviter VARCHAR(40) not null default '' COMMENT '邀请者uid';
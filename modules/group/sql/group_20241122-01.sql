@@ -0,0 +1,6 @@
+-- +migrate Up
+
+ALTER TABLE `group_member` ADD COLUMN role smallint not null default 0 COMMENT '成员角色 0.普通成员 1.群主 2.管理员';
+
+-- 已有群的创建者设置为群主
+UPDATE `group_member` m INNER JOIN `group` g ON m.group_no=g.group_no AND m.uid=g.creator SET m.role=1;
@@ -0,0 +1,5 @@
+-- +migrate Up
+
+ALTER TABLE `group` ADD COLUMN forbidden smallint not null default 0 COMMENT '是否全员禁言 0.否 1.是（只有群主和管理员能发言）';
+ALTER TABLE `group_blacklist` ADD COLUMN expired_at BIGINT not null default 0 COMMENT '禁言到期时间（unix秒），0表示不过期';
+CREATE INDEX group_blacklist_expiredAt on `group_blacklist` (expired_at);
@@ -0,0 +1,8 @@
+-- +migrate Up
+
+-- 群成员表之前创建的群，成员只存在于悟空IM的订阅者内，数据源不返回这些群的订阅者（由悟空IM使用自己的订阅者）
+ALTER TABLE `group` ADD COLUMN legacy smallint not null default 0 COMMENT '是否是群成员表之前创建的群 0.否 1.是';
+UPDATE `group` g SET g.legacy=1 WHERE NOT EXISTS (SELECT 1 FROM `group_member` m WHERE m.group_no=g.group_no);
+
+-- 已有群的创建者补为群主，保证群主能管理旧群
+INSERT IGNORE INTO `group_member` (group_no, uid, role) SELECT group_no, creator, 1 FROM `group` WHERE legacy=1 AND creator<>'';
@@ -3,8 +3,10 @@ package message
 import (
 	"embed"
 
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/common"
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/register"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/base"
 )
 
 //go:embed sql
@@ -14,10 +16,22 @@ func init() {
 
 	register.AddModule(func(ctx interface{}) register.Module {
 
+		api := New(ctx.(*config.Context))
+		// 处理新消息，生成@提醒项
+		base.AddMessageHandler(base.MessageHandler{
+			Name:         "mentionReminder",
+			ContentTypes: []common.ContentType{common.Text, common.RichText},
+			Handle:       api.handleMentionMessages,
+		})
+		// 处理新消息，取消被隐藏的最近会话
+		base.AddMessageHandler(base.MessageHandler{
+			Name:   "hiddenConversation",
+			Handle: api.handleHiddenConversations,
+		})
 		return register.Module{
 			Name: "message",
 			SetupAPI: func() register.APIRouter {
-				return New(ctx.(*config.Context))
+				return api
 			},
 			SQLDir: register.NewSQLFS(sqlFS),
 		}
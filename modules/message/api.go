@@ -10,10 +10,10 @@ import (
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/common"
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/log"
-	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/network"
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/util"
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/wkhttp"
 	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/base"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/group"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 )
@@ -25,6 +25,12 @@ type Message struct {
 	messageExtraDB     *messageExtraDB
 	messageUserExtraDB *messageUserExtraDB
 	channelOffsetDB    *channelOffsetDB
+	pinnedMessageDB    *pinnedMessageDB
+	messageReactionDB  *messageReactionDB
+	groupService       group.IService
+	remindersDB        *remindersDB
+	channelSettingDB   *channelSettingDB
+	channelSeqDB       *channelSeqDB
 }
 
 // New New
@@ -35,6 +41,12 @@ func New(ctx *config.Context) *Message {
 		messageExtraDB:     newMessageExtraDB(ctx),
 		messageUserExtraDB: newMessageUserExtraDB(ctx),
 		channelOffsetDB:    newChannelOffsetDB(ctx),
+		pinnedMessageDB:    newPinnedMessageDB(ctx),
+		messageReactionDB:  newMessageReactionDB(ctx),
+		groupService:       group.NewService(ctx),
+		remindersDB:        newRemindersDB(ctx),
+		channelSettingDB:   newChannelSettingDB(ctx),
+		channelSeqDB:       newChannelSeqDB(ctx),
 	}
 	return m
 }
@@ -48,6 +60,22 @@ func (m *Message) Route(r *wkhttp.WKHttp) {
 		message.POST("/channel/sync", m.syncChannelMessage) // 同步频道消息
 		message.POST("/extra/sync", m.syncMessageExtra)     // 同步消息扩展
 		message.POST("/offset", m.offset)                   // 清除频道消息
+		message.POST("/offset/channel", m.channelOffset)    // 清除所有成员的频道消息
+		message.POST("/edit", m.edit)                       // 编辑消息
+		message.POST("/pin", m.pin)                         // 置顶消息
+		message.POST("/unpin", m.unpin)                     // 取消置顶
+		message.POST("/pinned/sync", m.syncPinnedMessage)   // 同步置顶消息
+		message.POST("/readed", m.readed)                   // 消息已读
+		message.POST("/voicereaded", m.voiceReaded)         // 语音已读
+		message.GET("/:message_id/receipt", m.receipt)      // 消息已读成员列表
+		message.POST("/reminder/sync", m.syncReminder)      // 同步提醒项
+		message.POST("/reminder/done", m.reminderDone)      // 提醒项已完成
+	}
+	reaction := r.Group("/v1/reaction")
+	{
+		reaction.POST("", m.reactionAdd)       // 添加回应
+		reaction.DELETE("", m.reactionRemove)  // 取消回应
+		reaction.POST("/sync", m.syncReaction) // 同步回应
 	}
 
 }
@@ -97,23 +125,18 @@ func (m *Message) offset(c *wkhttp.Context) {
 		return
 	}
 	// 清除红点
-	resp, err := network.Post(base.APIURL+"/conversations/setUnread", []byte(util.ToJson(&config.ClearConversationUnreadReq{
+	err = base.IM().SetUnread(&config.ClearConversationUnreadReq{
 		UID:         req.LoginUID,
 		ChannelID:   req.ChannelID,
 		ChannelType: req.ChannelType,
 		MessageSeq:  req.MessageSeq,
 		Unread:      0,
-	})), nil)
+	})
 	if err != nil {
 		m.Error("清除红点失败！", zap.Error(err))
 		c.ResponseError(errors.New("清除红点失败！"))
 		return
 	}
-	err = base.HandlerIMError(resp)
-	if err != nil {
-		c.ResponseError(err)
-		return
-	}
 	// 发给指定频道
 	err = base.SendCMD(config.MsgCMDReq{
 		NoPersist:   true,
@@ -131,6 +154,94 @@ func (m *Message) offset(c *wkhttp.Context) {
 		c.ResponseError(errors.New("发送撤回消息失败！"))
 		return
 	}
+	// 通知自己的其他设备频道消息已清除
+	err = base.SendCMD(config.MsgCMDReq{
+		NoPersist:   true,
+		ChannelID:   req.LoginUID,
+		ChannelType: common.ChannelTypePerson.Uint8(),
+		CMD:         "channelOffsetUpdated",
+		Param: map[string]interface{}{
+			"channel_id":   req.ChannelID,
+			"channel_type": req.ChannelType,
+			"message_seq":  req.MessageSeq,
+		},
+	})
+	if err != nil {
+		m.Error("发送清除频道消息cmd失败！", zap.Error(err))
+		c.ResponseError(errors.New("发送清除频道消息cmd失败！"))
+		return
+	}
+	c.ResponseOK()
+}
+
+// 清除频道内所有成员的消息（仅群主或管理员）
+func (m *Message) channelOffset(c *wkhttp.Context) {
+	var req struct {
+		LoginUID    string `json:"login_uid"`
+		ChannelID   string `json:"channel_id"`
+		ChannelType uint8  `json:"channel_type"`
+		MessageSeq  uint32 `json:"message_seq"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.ResponseErrorf("数据格式有误！", err)
+		return
+	}
+	if req.ChannelID == "" {
+		c.ResponseError(errors.New("频道ID不能为空！"))
+		return
+	}
+	if req.LoginUID == "" {
+		c.ResponseError(errors.New("登录用户uid不能为空"))
+		return
+	}
+	if req.ChannelType != common.ChannelTypeGroup.Uint8() {
+		c.ResponseError(errors.New("只有群聊才能清除所有成员的消息！"))
+		return
+	}
+	if req.MessageSeq == 0 {
+		c.ResponseError(errors.New("消息序号不能为空！"))
+		return
+	}
+	isManager, err := m.groupService.IsManager(req.ChannelID, req.LoginUID)
+	if err != nil {
+		m.Error("查询群成员角色错误", zap.Error(err))
+		c.ResponseError(errors.New("查询群成员角色错误"))
+		return
+	}
+	if !isManager {
+		c.ResponseError(errors.New("只有群主或管理员才能操作！"))
+		return
+	}
+	// uid为空表示对频道内所有成员生效
+	err = m.channelOffsetDB.insertOrUpdate(&channelOffsetModel{
+		UID:         "",
+		ChannelID:   req.ChannelID,
+		ChannelType: req.ChannelType,
+		MessageSeq:  req.MessageSeq,
+	})
+	if err != nil {
+		m.Error("清除失败！", zap.Error(err))
+		c.ResponseError(errors.New("清除失败！"))
+		return
+	}
+	// 通知频道内所有成员
+	err = base.SendCMD(config.MsgCMDReq{
+		NoPersist:   true,
+		FromUID:     req.LoginUID,
+		ChannelID:   req.ChannelID,
+		ChannelType: req.ChannelType,
+		CMD:         "channelOffsetUpdated",
+		Param: map[string]interface{}{
+			"channel_id":   req.ChannelID,
+			"channel_type": req.ChannelType,
+			"message_seq":  req.MessageSeq,
+		},
+	})
+	if err != nil {
+		m.Error("发送清除频道消息cmd失败！", zap.Error(err))
+		c.ResponseError(errors.New("发送清除频道消息cmd失败！"))
+		return
+	}
 	c.ResponseOK()
 }
 
@@ -186,8 +297,22 @@ func (m *Message) syncMessageExtra(c *wkhttp.Context) {
 	}
 	resps := make([]*messageExtraResp, 0, len(extraModels))
 	if len(extraModels) > 0 {
+		messageIDs := make([]string, 0, len(extraModels))
 		for _, extraModel := range extraModels {
-			resps = append(resps, newMessageExtraResp(extraModel))
+			messageIDs = append(messageIDs, extraModel.MessageID)
+		}
+		// 用户对消息的已读状态
+		messageUserExtraModels, err := m.messageUserExtraDB.queryWithMessageIDsAndUID(messageIDs, req.LoginUID)
+		if err != nil {
+			c.ResponseErrorf("查询用户消息扩展数据失败！", err)
+			return
+		}
+		messageUserExtraMap := map[string]*messageUserExtraModel{}
+		for _, messageUserExtraM := range messageUserExtraModels {
+			messageUserExtraMap[messageUserExtraM.MessageID] = messageUserExtraM
+		}
+		for _, extraModel := range extraModels {
+			resps = append(resps, newMessageExtraResp(extraModel, messageUserExtraMap[extraModel.MessageID]))
 		}
 	}
 	c.Response(resps)
@@ -202,24 +327,12 @@ func (m *Message) syncChannelMessage(c *wkhttp.Context) {
 		return
 	}
 
-	resp, err := network.Post(base.APIURL+"/channel/messagesync", []byte(util.ToJson(req)), nil)
+	syncChannelMessageResp, err := base.IM().SyncChannelMessages(req)
 	if err != nil {
 		m.Error("同步频道消息错误", zap.Error(err))
 		c.ResponseError(errors.New("同步频道消息错误"))
 		return
 	}
-	err = base.HandlerIMError(resp)
-	if err != nil {
-		c.ResponseError(err)
-		return
-	}
-	var syncChannelMessageResp *config.SyncChannelMessageResp
-	err = util.ReadJsonByByte([]byte(resp.Body), &syncChannelMessageResp)
-	if err != nil {
-		m.Error("同步频道消息解析错误", zap.Error(err))
-		c.ResponseError(errors.New("同步频道消息解析错误"))
-		return
-	}
 
 	fmt.Println("resp----messages-->", len(syncChannelMessageResp.Messages))
 	channelOffset, err := m.channelOffsetDB.queryWithUIDAndChannel(req.LoginUID, req.ChannelID, req.ChannelType)
@@ -233,7 +346,7 @@ func (m *Message) syncChannelMessage(c *wkhttp.Context) {
 		channelOffsetMessageSeq = channelOffset.MessageSeq
 
 	}
-	c.Response(newSyncChannelMessageResp(syncChannelMessageResp, req.LoginUID, m.messageExtraDB, m.messageUserExtraDB, channelOffsetMessageSeq))
+	c.Response(newSyncChannelMessageResp(syncChannelMessageResp, req.LoginUID, m.messageExtraDB, m.messageUserExtraDB, m.messageReactionDB, channelOffsetMessageSeq))
 }
 
 // 删除消息
@@ -248,20 +361,199 @@ func (m *Message) delete(c *wkhttp.Context) {
 		c.ResponseError(err)
 		return
 	}
+	if req.Mutual == 1 {
+		m.mutualDelete(c, req)
+		return
+	}
 
-	err := m.messageUserExtraDB.insertOrUpdateDeleted(&messageUserExtraModel{
-		UID:              req.LoginUID,
-		MessageID:        req.MessageID,
-		MessageSeq:       req.MessageSeq,
-		ChannelID:        req.ChannelID,
-		ChannelType:      req.ChannelType,
-		MessageIsDeleted: 1,
-	})
+	models, err := m.getDeleteMessages(req)
+	if err != nil {
+		c.ResponseError(err)
+		return
+	}
+	err = m.messageUserExtraDB.insertOrUpdateDeletedBatch(req.LoginUID, models)
 	if err != nil {
 		m.Error("删除消息失败！", zap.Error(err))
 		c.ResponseError(errors.New("删除消息失败！"))
 		return
 	}
+	// 通知自己的其他设备删除消息
+	messages := make([]map[string]interface{}, 0, len(models))
+	for _, model := range models {
+		messages = append(messages, map[string]interface{}{
+			"message_id":  model.MessageID,
+			"message_seq": model.MessageSeq,
+		})
+	}
+	err = base.SendCMD(config.MsgCMDReq{
+		NoPersist:   true,
+		ChannelID:   req.LoginUID,
+		ChannelType: common.ChannelTypePerson.Uint8(),
+		CMD:         "messageDeleted",
+		Param: map[string]interface{}{
+			"channel_id":   req.ChannelID,
+			"channel_type": req.ChannelType,
+			"messages":     messages,
+		},
+	})
+	if err != nil {
+		m.Error("发送删除消息cmd失败！", zap.Error(err))
+		c.ResponseError(errors.New("发送删除消息cmd失败！"))
+		return
+	}
+	c.ResponseOK()
+}
+
+// 获取需要删除的消息（单条、批量或者按序号范围）
+func (m *Message) getDeleteMessages(req *deleteReq) ([]*messageUserExtraModel, error) {
+	// 与已读、语音已读一致，个人频道存储的是fake频道ID
+	fakeChannelID := req.ChannelID
+	if req.ChannelType == common.ChannelTypePerson.Uint8() {
+		fakeChannelID = common.GetFakeChannelIDWith(req.ChannelID, req.LoginUID)
+	}
+	models := make([]*messageUserExtraModel, 0)
+	newModel := func(messageID string, messageSeq uint32) *messageUserExtraModel {
+		return &messageUserExtraModel{
+			UID:              req.LoginUID,
+			MessageID:        messageID,
+			MessageSeq:       messageSeq,
+			ChannelID:        fakeChannelID,
+			ChannelType:      req.ChannelType,
+			MessageIsDeleted: 1,
+		}
+	}
+	if req.MessageID != "" {
+		models = append(models, newModel(req.MessageID, req.MessageSeq))
+	}
+	for _, msg := range req.Messages {
+		models = append(models, newModel(msg.MessageID, msg.MessageSeq))
+	}
+	if req.StartMessageSeq > 0 {
+		seqs := make([]uint32, 0, req.EndMessageSeq-req.StartMessageSeq+1)
+		for seq := req.StartMessageSeq; seq <= req.EndMessageSeq; seq++ {
+			seqs = append(seqs, seq)
+		}
+		messageResp, err := base.IM().SearchMessages(&config.MsgSearchReq{
+			LoginUID:    req.LoginUID,
+			ChannelID:   fakeChannelID,
+			ChannelType: req.ChannelType,
+			MessageSeqs: seqs,
+		})
+		if err != nil {
+			m.Error("查询消息错误", zap.Error(err))
+			return nil, errors.New("查询消息错误")
+		}
+		if messageResp != nil {
+			for _, msg := range messageResp.Messages {
+				models = append(models, newModel(strconv.FormatInt(msg.MessageID, 10), msg.MessageSeq))
+			}
+		}
+	}
+	return models, nil
+}
+
+// 语音已读
+func (m *Message) voiceReaded(c *wkhttp.Context) {
+	var req *voiceReadedReq
+	if err := c.BindJSON(&req); err != nil {
+		m.Error("数据格式有误！", zap.Error(err))
+		c.ResponseError(errors.New("数据格式有误！"))
+		return
+	}
+	if err := req.check(); err != nil {
+		c.ResponseError(err)
+		return
+	}
+	fakeChannelID := req.ChannelID
+	if req.ChannelType == common.ChannelTypePerson.Uint8() {
+		fakeChannelID = common.GetFakeChannelIDWith(req.ChannelID, req.LoginUID)
+	}
+	err := m.messageUserExtraDB.insertOrUpdateVoiceReaded(&messageUserExtraModel{
+		UID:         req.LoginUID,
+		MessageID:   req.MessageID,
+		MessageSeq:  req.MessageSeq,
+		ChannelID:   fakeChannelID,
+		ChannelType: req.ChannelType,
+		VoiceReaded: 1,
+	})
+	if err != nil {
+		m.Error("标记语音已读失败！", zap.Error(err))
+		c.ResponseError(errors.New("标记语音已读失败！"))
+		return
+	}
+	// 通知自己的其他设备
+	err = base.SendCMD(config.MsgCMDReq{
+		NoPersist:   true,
+		ChannelID:   req.LoginUID,
+		ChannelType: common.ChannelTypePerson.Uint8(),
+		CMD:         "messageVoiceReaded",
+		Param: map[string]interface{}{
+			"message_id":   req.MessageID,
+			"message_seq":  req.MessageSeq,
+			"channel_id":   req.ChannelID,
+			"channel_type": req.ChannelType,
+		},
+	})
+	if err != nil {
+		m.Error("发送语音已读cmd失败！", zap.Error(err))
+		c.ResponseError(errors.New("发送语音已读cmd失败！"))
+		return
+	}
+	c.ResponseOK()
+}
+
+// 双向删除消息（单聊），双方都看不到此消息
+func (m *Message) mutualDelete(c *wkhttp.Context, req *deleteReq) {
+	fakeChannelID := common.GetFakeChannelIDWith(req.ChannelID, req.LoginUID)
+	messageResp, err := base.IM().GetMessagesWithSeqs(fakeChannelID, req.ChannelType, "", []uint32{req.MessageSeq})
+	if err != nil {
+		m.Error("查询消息错误", zap.Error(err))
+		c.ResponseError(errors.New("查询消息错误"))
+		return
+	}
+	var message *config.MessageResp
+	if messageResp != nil {
+		for _, msg := range messageResp.Messages {
+			if fmt.Sprintf("%d", msg.MessageID) == req.MessageID {
+				message = msg
+				break
+			}
+		}
+	}
+	if message == nil {
+		c.ResponseError(errors.New("消息不存在！"))
+		return
+	}
+	err = m.messageExtraDB.mutualDelete(&messageExtraModel{
+		MessageID:   req.MessageID,
+		MessageSeq:  req.MessageSeq,
+		FromUID:     message.FromUID,
+		ChannelID:   fakeChannelID,
+		ChannelType: req.ChannelType,
+		Version:     time.Now().Unix(),
+	})
+	if err != nil {
+		m.Error("双向删除消息失败！", zap.Error(err), zap.String("messageID", req.MessageID), zap.String("channelID", fakeChannelID))
+		c.ResponseError(errors.New("删除消息失败！"))
+		return
+	}
+	// 通知双方同步消息扩展
+	err = base.SendCMD(config.MsgCMDReq{
+		NoPersist:   true,
+		FromUID:     req.LoginUID,
+		ChannelID:   req.ChannelID,
+		ChannelType: req.ChannelType,
+		CMD:         common.CMDSyncMessageExtra,
+		Param: map[string]interface{}{
+			"channel_id":   req.ChannelID,
+			"channel_type": req.ChannelType,
+		},
+	})
+	if err != nil {
+		m.Error("发送同步消息扩展cmd失败！", zap.Error(err))
+		c.ResponseError(errors.New("发送同步消息扩展cmd失败！"))
+		return
+	}
 	c.ResponseOK()
 }
 
@@ -283,44 +575,55 @@ func (m *Message) revoke(c *wkhttp.Context) {
 		fakeChannelID = common.GetFakeChannelIDWith(req.ChannelID, req.LoginUID)
 	}
 
-	// 如果撤回的是艾特消息需要删除对应的提醒记录
-	// 具体可查看唐僧叨叨实现逻辑
-	// https://github.com/TangSengDaoDao/TangSengDaoDaoServer/blob/main/modules/message/api.go
-
-	// 这里需要查询clientMsgNo下的所有消息（存在重试消息，clientMsgNo相同，messageID不同），然后遍历进行撤回标记。
-	// 演示程序并没有实现这一步，具体可查看唐僧叨叨实现逻辑。
-	// https://github.com/TangSengDaoDao/TangSengDaoDaoServer/blob/main/modules/message/api.go revoke 方法
-	messageExtr, err := m.messageExtraDB.queryWithMessageID(req.MessageID)
+	// 查询clientMsgNo下的所有消息（存在重试消息，clientMsgNo相同，messageID不同）
+	messageResp, err := base.IM().SearchMessages(&config.MsgSearchReq{
+		ChannelID:    fakeChannelID,
+		ChannelType:  req.ChannelType,
+		ClientMsgNos: []string{req.ClientMsgNo},
+	})
 	if err != nil {
-		m.Error("查询消息扩展错误", zap.Error(err))
-		c.ResponseError(errors.New("查询消息扩展错误"))
+		m.Error("查询消息错误", zap.Error(err))
+		c.ResponseError(errors.New("查询消息错误"))
+		return
+	}
+	if messageResp == nil || len(messageResp.Messages) == 0 {
+		c.ResponseError(errors.New("消息不存在！"))
+		return
+	}
+	if err = m.checkRevokePermission(req, messageResp.Messages[0]); err != nil {
+		c.ResponseError(err)
 		return
 	}
 	version := time.Now().Unix()
-	if messageExtr == nil {
-		err = m.messageExtraDB.insert(&messageExtraModel{
-			MessageID:   req.MessageID,
-			MessageSeq:  req.MessageSeq,
-			FromUID:     req.LoginUID,
+	revokeModels := make([]*messageExtraModel, 0, len(messageResp.Messages))
+	for _, message := range messageResp.Messages {
+		revokeModels = append(revokeModels, &messageExtraModel{
+			MessageID:   strconv.FormatInt(message.MessageID, 10),
+			MessageSeq:  message.MessageSeq,
+			FromUID:     message.FromUID,
 			ChannelID:   fakeChannelID,
 			ChannelType: req.ChannelType,
-			ReadedCount: 0,
-			Version:     version,
-			Revoke:      1,
 			Revoker:     req.LoginUID,
+			Version:     version,
 		})
-		if err != nil {
-			m.Error("新增消息扩展数据失败！", zap.Error(err), zap.String("messageID", req.MessageID), zap.String("channelID", fakeChannelID))
-			return
+	}
+	err = m.messageExtraDB.revokeWithMessages(revokeModels)
+	if err != nil {
+		m.Error("撤回消息失败！", zap.Error(err), zap.String("clientMsgNo", req.ClientMsgNo), zap.String("channelID", fakeChannelID))
+		c.ResponseError(errors.New("撤回消息失败！"))
+		return
+	}
+	// 如果撤回的是艾特消息需要删除对应的提醒记录
+	if req.ChannelType == common.ChannelTypeGroup.Uint8() {
+		messageIDs := make([]string, 0, len(revokeModels))
+		for _, revokeModel := range revokeModels {
+			messageIDs = append(messageIDs, revokeModel.MessageID)
 		}
-	} else {
-		messageExtr.Revoke = 1
-		messageExtr.Revoker = req.LoginUID
-		messageExtr.Version = version
-		err = m.messageExtraDB.update(messageExtr)
+		err = m.remindersDB.deleteWithMessageIDs(messageIDs, time.Now().UnixNano()/int64(time.Millisecond))
 		if err != nil {
-			m.Error("更新消息扩展数据失败！", zap.Error(err), zap.String("messageID", req.MessageID), zap.String("channelID", fakeChannelID))
-			return
+			m.Error("删除消息的提醒项失败！", zap.Error(err), zap.String("clientMsgNo", req.ClientMsgNo))
+		} else {
+			m.sendSyncRemindersCMD(fakeChannelID, req.ChannelType)
 		}
 	}
 	messageIDI, _ := strconv.ParseInt(req.MessageID, 10, 64)
@@ -331,7 +634,8 @@ func (m *Message) revoke(c *wkhttp.Context) {
 		ChannelType: req.ChannelType,
 		CMD:         "messageRevoke",
 		Param: map[string]interface{}{
-			"message_id": fmt.Sprintf("%d", messageIDI),
+			"message_id":    fmt.Sprintf("%d", messageIDI),
+			"client_msg_no": req.ClientMsgNo,
 		},
 	})
 	if err != nil {
@@ -343,6 +647,31 @@ func (m *Message) revoke(c *wkhttp.Context) {
 
 }
 
+// 撤回权限判断，发送者只能在配置的时间内撤回，群主和管理员可以撤回群内任何消息
+func (m *Message) checkRevokePermission(req *revokeReq, message *config.MessageResp) error {
+	isSender := message.FromUID == req.LoginUID
+	if isSender {
+		revokeTimeout := base.GetConfig().Message.RevokeTimeout
+		if revokeTimeout <= 0 || time.Now().Unix()-int64(message.Timestamp) <= int64(revokeTimeout.Seconds()) {
+			return nil
+		}
+	}
+	if req.ChannelType == common.ChannelTypeGroup.Uint8() {
+		isManager, err := m.groupService.IsManager(req.ChannelID, req.LoginUID)
+		if err != nil {
+			m.Error("查询群成员角色错误", zap.Error(err))
+			return errors.New("查询群成员角色错误")
+		}
+		if isManager {
+			return nil
+		}
+	}
+	if isSender {
+		return errors.New("消息已超过可撤回的时间！")
+	}
+	return errors.New("没有权限撤回该消息！")
+}
+
 func (m *Message) getMessageExtraVersion(uid, source, channelID string, channelType uint8) (int64, error) {
 	versionStr, err := m.ctx.GetRedisConn().Hget(fmt.Sprintf("messageExtraVersion:%s%s", uid, source), fmt.Sprintf("%s-%d", channelID, channelType))
 	if err != nil {
@@ -374,7 +703,7 @@ type syncChannelMessageResp struct {
 	Messages        []*MsgSyncResp  `json:"messages"`          // 消息数据
 }
 
-func newSyncChannelMessageResp(resp *config.SyncChannelMessageResp, loginUID string, messageExtraDB *messageExtraDB, messageUserExtraDB *messageUserExtraDB, channelOffsetMessageSeq uint32) *syncChannelMessageResp {
+func newSyncChannelMessageResp(resp *config.SyncChannelMessageResp, loginUID string, messageExtraDB *messageExtraDB, messageUserExtraDB *messageUserExtraDB, messageReactionDB *messageReactionDB, channelOffsetMessageSeq uint32) *syncChannelMessageResp {
 	messages := make([]*MsgSyncResp, 0, len(resp.Messages))
 	if len(resp.Messages) > 0 {
 		messageIDs := make([]string, 0, len(resp.Messages))
@@ -406,13 +735,16 @@ func newSyncChannelMessageResp(resp *config.SyncChannelMessageResp, loginUID str
 			}
 		}
 
+		// 消息回应
+		reactionMap := queryReactionMap(messageReactionDB, messageIDs)
+
 		// 设备偏移
 		for _, message := range resp.Messages {
 			messageIDStr := strconv.FormatInt(message.MessageID, 10)
 			messageExtra := messageExtraMap[messageIDStr]
 			messageUserExtra := messageUserExtraMap[messageIDStr]
 			msgResp := &MsgSyncResp{}
-			msgResp.from(message, loginUID, messageExtra, messageUserExtra, channelOffsetMessageSeq)
+			msgResp.from(message, loginUID, messageExtra, messageUserExtra, reactionMap[messageIDStr], channelOffsetMessageSeq)
 			messages = append(messages, msgResp)
 		}
 	}
@@ -464,10 +796,12 @@ type MsgSyncResp struct {
 
 	// 消息扩展字段
 	MessageExtra *messageExtraResp `json:"message_extra,omitempty"` // 消息扩展
+	// 消息回应
+	Reactions []*reactionSimpleResp `json:"reactions,omitempty"` // 回应数据
 
 }
 
-func (m *MsgSyncResp) from(msgResp *config.MessageResp, loginUID string, messageExtraM *messageExtraModel, messageUserExtraM *messageUserExtraModel, channelOffsetMessageSeq uint32) {
+func (m *MsgSyncResp) from(msgResp *config.MessageResp, loginUID string, messageExtraM *messageExtraModel, messageUserExtraM *messageUserExtraModel, reactionModels []*messageReactionDetailModel, channelOffsetMessageSeq uint32) {
 	m.Header.NoPersist = msgResp.Header.NoPersist
 	m.Header.RedDot = msgResp.Header.RedDot
 	m.Header.SyncOnce = msgResp.Header.SyncOnce
@@ -491,7 +825,7 @@ func (m *MsgSyncResp) from(msgResp *config.MessageResp, loginUID string, message
 		m.ReadedCount = messageExtraM.ReadedCount
 		m.ExtraVersion = messageExtraM.Version
 
-		m.MessageExtra = newMessageExtraResp(messageExtraM)
+		m.MessageExtra = newMessageExtraResp(messageExtraM, messageUserExtraM)
 	}
 
 	setting := config.SettingFromUint8(msgResp.Setting)
@@ -531,6 +865,7 @@ func (m *MsgSyncResp) from(msgResp *config.MessageResp, loginUID string, message
 			m.IsDeleted = messageUserExtraM.MessageIsDeleted
 		}
 		m.VoiceStatus = messageUserExtraM.VoiceReaded
+		m.Readed = messageUserExtraM.Readed
 	}
 
 	if msgResp.Expire > 0 {
@@ -543,20 +878,20 @@ func (m *MsgSyncResp) from(msgResp *config.MessageResp, loginUID string, message
 	}
 	m.Payload = payloadMap
 
-	// msgReactionList := make([]*reactionSimpleResp, 0, len(reactionModels))
-	// if len(reactionModels) > 0 {
-	// 	for _, reaction := range reactionModels {
-	// 		msgReactionList = append(msgReactionList, &reactionSimpleResp{
-	// 			UID:       reaction.UID,
-	// 			Name:      reaction.Name,
-	// 			Seq:       reaction.Seq,
-	// 			IsDeleted: reaction.IsDeleted,
-	// 			Emoji:     reaction.Emoji,
-	// 			CreatedAt: reaction.CreatedAt.String(),
-	// 		})
-	// 	}
-	// }
-	// m.Reactions = msgReactionList
+	if len(reactionModels) > 0 {
+		msgReactionList := make([]*reactionSimpleResp, 0, len(reactionModels))
+		for _, reaction := range reactionModels {
+			msgReactionList = append(msgReactionList, &reactionSimpleResp{
+				UID:       reaction.UID,
+				Name:      reaction.Name,
+				Seq:       reaction.Seq,
+				IsDeleted: reaction.IsDeleted,
+				Emoji:     reaction.Emoji,
+				CreatedAt: reaction.CreatedAt.String(),
+			})
+		}
+		m.Reactions = msgReactionList
+	}
 
 	if len(msgResp.Streams) > 0 {
 		streams := make([]*streamItemResp, 0, len(msgResp.Streams))
@@ -609,7 +944,42 @@ type deleteReq struct {
 	ChannelID   string `json:"channel_id"`
 	ChannelType uint8  `json:"channel_type"`
 	MessageSeq  uint32 `json:"message_seq"`
+	Mutual      int    `json:"mutual"` // 是否双向删除（仅单聊） 0.否 1.是
+	// 批量删除的消息
+	Messages []*deleteMessageReq `json:"messages"`
+	// 按序号范围删除 [start_message_seq,end_message_seq]
+	StartMessageSeq uint32 `json:"start_message_seq"`
+	EndMessageSeq   uint32 `json:"end_message_seq"`
 }
+
+type deleteMessageReq struct {
+	MessageID  string `json:"message_id"`
+	MessageSeq uint32 `json:"message_seq"`
+}
+type voiceReadedReq struct {
+	LoginUID    string `json:"login_uid"`
+	MessageID   string `json:"message_id"`
+	MessageSeq  uint32 `json:"message_seq"`
+	ChannelID   string `json:"channel_id"`
+	ChannelType uint8  `json:"channel_type"`
+}
+
+func (v *voiceReadedReq) check() error {
+	if strings.TrimSpace(v.MessageID) == "" {
+		return errors.New("消息ID不能为空！")
+	}
+	if strings.TrimSpace(v.ChannelID) == "" {
+		return errors.New("频道ID不能为空！")
+	}
+	if strings.TrimSpace(v.LoginUID) == "" {
+		return errors.New("uid不能为空")
+	}
+	if v.ChannelType == 0 {
+		return errors.New("频道类型不能为空！")
+	}
+	return nil
+}
+
 type revokeReq struct {
 	LoginUID    string `json:"login_uid"`
 	MessageID   string `json:"message_id"`
@@ -635,7 +1005,7 @@ func (r *revokeReq) check() error {
 	return nil
 }
 func (d *deleteReq) check() error {
-	if strings.TrimSpace(d.MessageID) == "" {
+	if strings.TrimSpace(d.MessageID) == "" && len(d.Messages) == 0 && d.StartMessageSeq == 0 {
 		return errors.New("消息ID不能为空！")
 	}
 	if strings.TrimSpace(d.ChannelID) == "" {
@@ -647,13 +1017,40 @@ func (d *deleteReq) check() error {
 	if d.ChannelType == 0 {
 		return errors.New("频道类型不能为空！")
 	}
-	if d.MessageSeq == 0 {
+	if d.MessageID != "" && d.MessageSeq == 0 {
 		return errors.New("消息序号不能为空！")
 	}
+	if len(d.Messages) > maxDeleteMessageCount {
+		return fmt.Errorf("一次最多删除%d条消息！", maxDeleteMessageCount)
+	}
+	for _, msg := range d.Messages {
+		if strings.TrimSpace(msg.MessageID) == "" || msg.MessageSeq == 0 {
+			return errors.New("消息ID和消息序号不能为空！")
+		}
+	}
+	if d.StartMessageSeq > 0 {
+		if d.EndMessageSeq < d.StartMessageSeq {
+			return errors.New("结束序号不能小于开始序号！")
+		}
+		if d.EndMessageSeq-d.StartMessageSeq >= maxDeleteMessageCount {
+			return fmt.Errorf("一次最多删除%d条消息！", maxDeleteMessageCount)
+		}
+	}
+	if d.Mutual == 1 {
+		if d.ChannelType != common.ChannelTypePerson.Uint8() {
+			return errors.New("只有单聊消息才能双向删除！")
+		}
+		if d.MessageID == "" || len(d.Messages) > 0 || d.StartMessageSeq > 0 {
+			return errors.New("双向删除只支持单条消息！")
+		}
+	}
 	return nil
 }
 
-func newMessageExtraResp(m *messageExtraModel) *messageExtraResp {
+// 一次最多删除的消息数量
+const maxDeleteMessageCount = 1000
+
+func newMessageExtraResp(m *messageExtraModel, messageUserExtraM *messageUserExtraModel) *messageExtraResp {
 
 	messageID, _ := strconv.ParseInt(m.MessageID, 10, 64)
 
@@ -665,18 +1062,30 @@ func newMessageExtraResp(m *messageExtraModel) *messageExtraResp {
 		}
 	}
 
-	var readedAt int64 = 0
+	var (
+		readed      int
+		readedAt    int64
+		voiceStatus int
+	)
+	if messageUserExtraM != nil {
+		readed = messageUserExtraM.Readed
+		readedAt = messageUserExtraM.ReadedAt
+		voiceStatus = messageUserExtraM.VoiceReaded
+	}
 
 	return &messageExtraResp{
 		MessageID:       messageID,
 		MessageIDStr:    m.MessageID,
 		Revoke:          m.Revoke,
 		Revoker:         m.Revoker,
+		VoiceStatus:     voiceStatus,
+		Readed:          readed,
 		ReadedAt:        readedAt,
 		ReadedCount:     m.ReadedCount,
 		ContentEdit:     contentEditMap,
 		EditedAt:        m.EditedAt,
 		IsMutualDeleted: m.IsDeleted,
+		IsPinned:        m.IsPinned,
 		ExtraVersion:    m.Version,
 	}
 }
@@ -0,0 +1,176 @@
+package message
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/common"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/wkhttp"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/base"
+	"go.uber.org/zap"
+)
+
+// 更新用户的频道设置（置顶、免打扰、隐藏、备注），只修改请求内存在的字段
+// 免打扰时清空悟空IM内此会话的红点，之后的红点在同步最近会话时过滤，离线推送由业务端根据免打扰过滤
+func (co *Conversation) updateSetting(c *wkhttp.Context) {
+	channelID := c.Param("channel_id")
+	channelTypeI64, _ := strconv.ParseInt(c.Param("channel_type"), 10, 64)
+	var req struct {
+		LoginUID string  `json:"login_uid"`
+		Top      *int    `json:"top"`
+		Mute     *int    `json:"mute"`
+		Hide     *int    `json:"hide"`
+		Remark   *string `json:"remark"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		co.Error("数据格式有误！", zap.Error(err))
+		c.ResponseError(errors.New("数据格式有误！"))
+		return
+	}
+	if strings.TrimSpace(req.LoginUID) == "" {
+		c.ResponseError(errors.New("登录用户ID不能为空"))
+		return
+	}
+	if strings.TrimSpace(channelID) == "" || channelTypeI64 == 0 {
+		c.ResponseError(errors.New("频道ID和频道类型不能为空！"))
+		return
+	}
+	settingMap := map[string]interface{}{}
+	for column, value := range map[string]*int{"top": req.Top, "mute": req.Mute, "hide": req.Hide} {
+		if value == nil {
+			continue
+		}
+		if *value != 0 && *value != 1 {
+			c.ResponseError(errors.New("设置的值只能是0或1！"))
+			return
+		}
+		settingMap[column] = *value
+	}
+	if req.Remark != nil {
+		if len([]rune(*req.Remark)) > 50 {
+			c.ResponseError(errors.New("备注不能超过50个字符！"))
+			return
+		}
+		settingMap["remark"] = *req.Remark
+	}
+	if len(settingMap) == 0 {
+		c.ResponseError(errors.New("没有需要修改的设置！"))
+		return
+	}
+	err := co.channelSettingDB.insertOrUpdate(req.LoginUID, channelID, uint8(channelTypeI64), settingMap, time.Now().UnixNano()/int64(time.Millisecond))
+	if err != nil {
+		co.Error("更新频道设置失败！", zap.Error(err))
+		c.ResponseError(errors.New("更新频道设置失败！"))
+		return
+	}
+	if req.Mute != nil && *req.Mute == 1 {
+		err = base.IM().SetUnread(&config.ClearConversationUnreadReq{
+			UID:         req.LoginUID,
+			ChannelID:   channelID,
+			ChannelType: uint8(channelTypeI64),
+			Unread:      0,
+		})
+		if err != nil {
+			co.Error("清空免打扰会话的红点失败！", zap.Error(err))
+			c.ResponseError(errors.New("清空免打扰会话的红点失败！"))
+			return
+		}
+	}
+	// 通知自己的其他设备更新频道信息
+	err = base.SendCMD(config.MsgCMDReq{
+		NoPersist:   true,
+		ChannelID:   req.LoginUID,
+		ChannelType: common.ChannelTypePerson.Uint8(),
+		CMD:         common.CMDChannelUpdate,
+		Param: map[string]interface{}{
+			"channel_id":   channelID,
+			"channel_type": channelTypeI64,
+		},
+	})
+	if err != nil {
+		co.Error("命令发送失败！", zap.String("cmd", common.CMDChannelUpdate))
+		c.ResponseError(errors.New("命令发送失败！"))
+		return
+	}
+	c.ResponseOK()
+}
+
+// 获取用户的频道设置
+func (co *Conversation) getSetting(c *wkhttp.Context) {
+	channelID := c.Param("channel_id")
+	channelTypeI64, _ := strconv.ParseInt(c.Param("channel_type"), 10, 64)
+	loginUID := c.Query("login_uid")
+	if strings.TrimSpace(loginUID) == "" {
+		c.ResponseError(errors.New("登录用户ID不能为空"))
+		return
+	}
+	model, err := co.channelSettingDB.queryWithUIDAndChannel(loginUID, channelID, uint8(channelTypeI64))
+	if err != nil {
+		co.Error("查询频道设置失败！", zap.Error(err))
+		c.ResponseError(errors.New("查询频道设置失败！"))
+		return
+	}
+	if model == nil {
+		model = &channelSettingModel{
+			UID:         loginUID,
+			ChannelID:   channelID,
+			ChannelType: uint8(channelTypeI64),
+		}
+	}
+	c.Response(newChannelSettingResp(model))
+}
+
+// 频道有新消息时，取消用户对此频道的隐藏
+func (m *Message) handleHiddenConversations(messages []*config.MessageResp) {
+	version := time.Now().UnixNano() / int64(time.Millisecond)
+	channelMap := make(map[string]bool)
+	for _, message := range messages {
+		if message.Header.NoPersist == 1 {
+			continue
+		}
+		var err error
+		if message.ChannelType == common.ChannelTypePerson.Uint8() {
+			uid1, uid2 := message.FromUID, message.ChannelID
+			if strings.Contains(message.ChannelID, "@") {
+				uids := strings.Split(message.ChannelID, "@")
+				uid1, uid2 = uids[0], uids[1]
+			}
+			err = m.channelSettingDB.unhideWithPerson(uid1, uid2, version)
+		} else {
+			channelKey := message.ChannelID + "-" + strconv.Itoa(int(message.ChannelType))
+			if channelMap[channelKey] {
+				continue
+			}
+			channelMap[channelKey] = true
+			err = m.channelSettingDB.unhideWithChannel(message.ChannelID, message.ChannelType, version)
+		}
+		if err != nil {
+			m.Error("取消隐藏最近会话失败！", zap.Error(err), zap.String("channelID", message.ChannelID))
+		}
+	}
+}
+
+type channelSettingResp struct {
+	ChannelID   string `json:"channel_id"`
+	ChannelType uint8  `json:"channel_type"`
+	Top         int    `json:"top"`    // 是否置顶
+	Mute        int    `json:"mute"`   // 是否免打扰
+	Hide        int    `json:"hide"`   // 是否从最近会话列表隐藏
+	Remark      string `json:"remark"` // 备注
+	Version     int64  `json:"version"`
+}
+
+func newChannelSettingResp(m *channelSettingModel) *channelSettingResp {
+	return &channelSettingResp{
+		ChannelID:   m.ChannelID,
+		ChannelType: m.ChannelType,
+		Top:         m.Top,
+		Mute:        m.Mute,
+		Hide:        m.Hide,
+		Remark:      m.Remark,
+		Version:     m.Version,
+	}
+}
@@ -4,40 +4,44 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
-	"sync"
+	"strings"
+	"time"
 
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/common"
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/log"
-	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/network"
-	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/util"
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/wkhttp"
 	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/base"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/pkg/imclient"
 	"go.uber.org/zap"
 )
 
+// 最近会话同步结果等待ack的过期时间
+const syncConversationResultExpire = time.Minute * 30
+
 // Conversation 最近会话
 type Conversation struct {
 	ctx *config.Context
 	log.Log
-	messageExtraDB                  *messageExtraDB
-	messageUserExtraDB              *messageUserExtraDB
-	channelOffsetDB                 *channelOffsetDB
-	syncConversationResultCacheMap  map[string][]string
-	syncConversationVersionMap      map[string]int64
-	syncConversationResultCacheLock sync.RWMutex
+	messageExtraDB      *messageExtraDB
+	messageUserExtraDB  *messageUserExtraDB
+	channelOffsetDB     *channelOffsetDB
+	messageReactionDB   *messageReactionDB
+	conversationExtraDB *conversationExtraDB
+	channelSettingDB    *channelSettingDB
 }
 
 // New New
 func NewConversation(ctx *config.Context) *Conversation {
 	return &Conversation{
-		ctx:                            ctx,
-		Log:                            log.NewTLog("Coversation"),
-		channelOffsetDB:                newChannelOffsetDB(ctx),
-		messageExtraDB:                 newMessageExtraDB(ctx),
-		messageUserExtraDB:             newMessageUserExtraDB(ctx),
-		syncConversationResultCacheMap: map[string][]string{},
-		syncConversationVersionMap:     map[string]int64{},
+		ctx:                 ctx,
+		Log:                 log.NewTLog("Coversation"),
+		channelOffsetDB:     newChannelOffsetDB(ctx),
+		messageExtraDB:      newMessageExtraDB(ctx),
+		messageUserExtraDB:  newMessageUserExtraDB(ctx),
+		messageReactionDB:   newMessageReactionDB(ctx),
+		conversationExtraDB: newConversationExtraDB(ctx),
+		channelSettingDB:    newChannelSettingDB(ctx),
 	}
 }
 
@@ -49,7 +53,12 @@ func (co *Conversation) Route(r *wkhttp.WKHttp) {
 
 		conversation.POST("/sync", co.syncUserConversation) // 离线的最近会话
 		conversation.POST("/syncack", co.syncUserConversationAck)
-		conversation.PUT("/clearUnread", co.clearUnread) // 清除用户未读消息
+		conversation.PUT("/clearUnread", co.clearUnread)                         // 清除用户未读消息
+		conversation.POST("/delete", co.deleteConversation)                      // 删除最近会话
+		conversation.POST("/extra/sync", co.syncExtra)                           // 同步最近会话扩展
+		conversation.POST("/extra/:channel_id/:channel_type", co.updateExtra)    // 更新最近会话扩展
+		conversation.PUT("/setting/:channel_id/:channel_type", co.updateSetting) // 更新频道设置
+		conversation.GET("/setting/:channel_id/:channel_type", co.getSetting)    // 获取频道设置
 	}
 
 }
@@ -65,23 +74,18 @@ func (co *Conversation) clearUnread(c *wkhttp.Context) {
 		c.ResponseError(errors.New("数据格式有误！"))
 		return
 	}
-	resp, err := network.Post(base.APIURL+"/conversations/setUnread", []byte(util.ToJson(&config.ClearConversationUnreadReq{
+	err := base.IM().SetUnread(&config.ClearConversationUnreadReq{
 		UID:         req.LoginUID,
 		ChannelID:   req.ChannelID,
 		ChannelType: req.ChannelType,
 		Unread:      req.Unread,
 		MessageSeq:  0,
-	})), nil)
+	})
 	if err != nil {
 		co.Error("清空红点错误", zap.Error(err))
 		c.ResponseError(errors.New("清空红点错误"))
 		return
 	}
-	err = base.HandlerIMError(resp)
-	if err != nil {
-		c.ResponseError(err)
-		return
-	}
 	// 发送清空红点的命令
 	err = base.SendCMD(config.MsgCMDReq{
 		NoPersist:   true,
@@ -102,6 +106,76 @@ func (co *Conversation) clearUnread(c *wkhttp.Context) {
 	c.ResponseOK()
 }
 
+// 删除最近会话
+func (co *Conversation) deleteConversation(c *wkhttp.Context) {
+	var req struct {
+		LoginUID    string `json:"login_uid"`    // 登录用户id
+		ChannelID   string `json:"channel_id"`   // 频道ID
+		ChannelType uint8  `json:"channel_type"` // 频道类型
+		Clear       int    `json:"clear"`        // 是否同时清空频道消息 0.否 1.是
+		MessageSeq  uint32 `json:"message_seq"`  // 频道最后一条消息的seq（清空消息时必传）
+	}
+	if err := c.BindJSON(&req); err != nil {
+		co.Error("数据格式有误！", zap.Error(err))
+		c.ResponseError(errors.New("数据格式有误！"))
+		return
+	}
+	if strings.TrimSpace(req.LoginUID) == "" {
+		c.ResponseError(errors.New("登录用户ID不能为空"))
+		return
+	}
+	if strings.TrimSpace(req.ChannelID) == "" || req.ChannelType == 0 {
+		c.ResponseError(errors.New("频道ID和频道类型不能为空！"))
+		return
+	}
+	if req.Clear == 1 && req.MessageSeq == 0 {
+		c.ResponseError(errors.New("清空消息时消息序号不能为空！"))
+		return
+	}
+	err := base.IM().DeleteConversation(&config.DeleteConversationReq{
+		UID:         req.LoginUID,
+		ChannelID:   req.ChannelID,
+		ChannelType: req.ChannelType,
+	})
+	if err != nil {
+		co.Error("删除IM最近会话失败！", zap.Error(err))
+		c.ResponseError(errors.New("删除IM最近会话失败！"))
+		return
+	}
+	if req.Clear == 1 {
+		err = co.channelOffsetDB.insertOrUpdate(&channelOffsetModel{
+			UID:         req.LoginUID,
+			ChannelID:   req.ChannelID,
+			ChannelType: req.ChannelType,
+			MessageSeq:  req.MessageSeq,
+		})
+		if err != nil {
+			co.Error("清除频道消息失败！", zap.Error(err))
+			c.ResponseError(errors.New("清除频道消息失败！"))
+			return
+		}
+	}
+	// 通知自己的其他设备删除最近会话
+	err = base.SendCMD(config.MsgCMDReq{
+		NoPersist:   true,
+		ChannelID:   req.LoginUID,
+		ChannelType: common.ChannelTypePerson.Uint8(),
+		CMD:         common.CMDConversationDeleted,
+		Param: map[string]interface{}{
+			"channel_id":   req.ChannelID,
+			"channel_type": req.ChannelType,
+			"clear":        req.Clear,
+			"message_seq":  req.MessageSeq,
+		},
+	})
+	if err != nil {
+		co.Error("命令发送失败！", zap.String("cmd", common.CMDConversationDeleted))
+		c.ResponseError(errors.New("命令发送失败！"))
+		return
+	}
+	c.ResponseOK()
+}
+
 // 获取离线的最近会话
 func (co *Conversation) syncUserConversation(c *wkhttp.Context) {
 	var req struct {
@@ -153,28 +227,17 @@ func (co *Conversation) syncUserConversation(c *wkhttp.Context) {
 		}
 	}
 
-	resp, err := network.Post(base.APIURL+"/conversation/sync", []byte(util.ToJson(map[string]interface{}{
-		"uid":           req.LoginUID,
-		"version":       version,
-		"last_msg_seqs": lastMsgSeqs,
-		"msg_count":     req.MsgCount,
-		"larges":        nil,
-	})), nil)
+	conversations, err := base.IM().SyncConversations(&imclient.SyncConversationsReq{
+		UID:         req.LoginUID,
+		Version:     version,
+		LastMsgSeqs: lastMsgSeqs,
+		MsgCount:    req.MsgCount,
+	})
 	if err != nil {
 		co.Error("获取IM离线最近会话失败！", zap.Error(err))
 		c.ResponseError(errors.New("获取IM离线最近会话失败！"))
 		return
 	}
-	err = base.HandlerIMError(resp)
-	if err != nil {
-		c.ResponseError(err)
-	}
-	var conversations []*config.SyncUserConversationResp
-	err = util.ReadJsonByByte([]byte(resp.Body), &conversations)
-	if err != nil {
-		c.ResponseError(errors.New("解析IM离线最近会话失败！"))
-		return
-	}
 	channelIDs := make([]string, 0, len(conversations))
 	if len(conversations) > 0 {
 		for _, conversation := range conversations {
@@ -202,42 +265,54 @@ func (co *Conversation) syncUserConversation(c *wkhttp.Context) {
 		}
 	}
 
+	// ---------- 最近会话扩展  ----------
+	conversationExtraMap := map[string]*conversationExtraModel{}
+	if len(channelIDs) > 0 {
+		conversationExtras, err := co.conversationExtraDB.queryWithUIDAndChannelIDs(loginUID, channelIDs)
+		if err != nil {
+			co.Error("查询最近会话扩展失败！", zap.Error(err))
+			c.ResponseError(errors.New("查询最近会话扩展失败！"))
+			return
+		}
+		for _, conversationExtra := range conversationExtras {
+			conversationExtraMap[fmt.Sprintf("%s-%d", conversationExtra.ChannelID, conversationExtra.ChannelType)] = conversationExtra
+		}
+	}
+
+	// ---------- 用户频道设置  ----------
+	channelSettingMap := map[string]*channelSettingModel{}
+	if len(channelIDs) > 0 {
+		channelSettings, err := co.channelSettingDB.queryWithUIDAndChannelIDs(loginUID, channelIDs)
+		if err != nil {
+			co.Error("查询用户频道设置失败！", zap.Error(err))
+			c.ResponseError(errors.New("查询用户频道设置失败！"))
+			return
+		}
+		for _, channelSetting := range channelSettings {
+			channelSettingMap[fmt.Sprintf("%s-%d", channelSetting.ChannelID, channelSetting.ChannelType)] = channelSetting
+		}
+	}
+
 	syncUserConversationResps := make([]*SyncUserConversationResp, 0, len(conversations))
-	userKey := loginUID
 	if len(conversations) > 0 {
 		for _, conversation := range conversations {
 			channelKey := fmt.Sprintf("%s-%d", conversation.ChannelID, conversation.ChannelType)
 			channelOffsetM := channelOffsetModelMap[channelKey]
-			// channelSetting := channelSettingMap[channelKey]
+			channelSetting := channelSettingMap[channelKey]
 			channelOffsetMsgSeq := uint32(0)
 			if channelOffsetM != nil {
 				channelOffsetMsgSeq = channelOffsetM.MessageSeq
 			}
 
-			syncUserConversationResp := newSyncUserConversationResp(conversation, loginUID, co.messageExtraDB, co.messageUserExtraDB, channelOffsetMsgSeq)
+			syncUserConversationResp := newSyncUserConversationResp(conversation, loginUID, co.messageExtraDB, co.messageUserExtraDB, co.messageReactionDB, channelOffsetMsgSeq)
+			if conversationExtra := conversationExtraMap[channelKey]; conversationExtra != nil {
+				syncUserConversationResp.Extra = newConversationExtraResp(conversationExtra)
+			}
 			if len(syncUserConversationResp.Recents) > 0 {
 				syncUserConversationResps = append(syncUserConversationResps, syncUserConversationResp)
 			}
-			// if channelSetting != nil {
-			// 	syncUserConversationResp.ParentChannelID = channelSetting.ParentChannelID
-			// 	syncUserConversationResp.ParentChannelType = channelSetting.ParentChannelType
-			// }
-
-			// 缓存频道对应的最新的消息messageSeq
-			if !co.ctx.GetConfig().MessageSaveAcrossDevice {
-
-				co.syncConversationResultCacheLock.RLock()
-				channelMessageSeqs := co.syncConversationResultCacheMap[userKey]
-				co.syncConversationResultCacheLock.RUnlock()
-				if channelMessageSeqs == nil {
-					channelMessageSeqs = make([]string, 0)
-				}
-				if len(syncUserConversationResp.Recents) > 0 {
-					channelMessageSeqs = append(channelMessageSeqs, co.channelMessageSeqJoin(conversation.ChannelID, conversation.ChannelType, syncUserConversationResp.Recents[0].MessageSeq))
-					co.syncConversationResultCacheLock.Lock()
-					co.syncConversationResultCacheMap[userKey] = channelMessageSeqs
-					co.syncConversationResultCacheLock.Unlock()
-				}
+			if channelSetting != nil {
+				syncUserConversationResp.setChannelSetting(channelSetting)
 			}
 		}
 	}
@@ -245,12 +320,15 @@ func (co *Conversation) syncUserConversation(c *wkhttp.Context) {
 	if len(syncUserConversationResps) > 0 {
 		lastVersion = syncUserConversationResps[len(syncUserConversationResps)-1].Version
 	}
-	co.syncConversationResultCacheLock.Lock()
-	cacheVersion := co.syncConversationVersionMap[userKey]
-	if cacheVersion < lastVersion {
-		co.syncConversationVersionMap[userKey] = lastVersion
+	// 缓存同步结果，等待客户端ack（多个服务实例共享）
+	if !co.ctx.GetConfig().MessageSaveAcrossDevice {
+		err = co.setSyncConversationResult(loginUID, req.DeviceUUID, lastVersion)
+		if err != nil {
+			co.Error("缓存最近会话同步结果失败！", zap.Error(err), zap.String("loginUID", loginUID), zap.String("deviceUUID", req.DeviceUUID))
+			c.ResponseError(errors.New("缓存最近会话同步结果失败！"))
+			return
+		}
 	}
-	co.syncConversationResultCacheLock.Unlock()
 
 	c.Response(SyncUserConversationRespWrap{
 		Conversations: syncUserConversationResps,
@@ -258,10 +336,6 @@ func (co *Conversation) syncUserConversation(c *wkhttp.Context) {
 	})
 }
 
-func (co *Conversation) channelMessageSeqJoin(channelID string, channelType uint8, lastMessageSeq uint32) string {
-	return fmt.Sprintf("%s:%d:%d", channelID, channelType, lastMessageSeq)
-}
-
 func (co *Conversation) syncUserConversationAck(c *wkhttp.Context) {
 	var req struct {
 		LoginUID   string `json:"login_uid"`   // 登录用户id
@@ -278,23 +352,61 @@ func (co *Conversation) syncUserConversationAck(c *wkhttp.Context) {
 		return
 	}
 
-	userKey := req.LoginUID
-
-	co.syncConversationResultCacheLock.RLock()
-	version := co.syncConversationVersionMap[userKey]
-	co.syncConversationResultCacheLock.RUnlock()
+	version, err := co.getSyncConversationVersion(req.LoginUID, req.DeviceUUID)
+	if err != nil {
+		co.Error("获取最近会话同步结果失败！", zap.Error(err))
+		c.ResponseError(errors.New("获取最近会话同步结果失败！"))
+		return
+	}
 	if version > 0 {
-		err := co.setUserConversationMaxVersion(req.LoginUID, version)
+		err = co.setUserConversationMaxVersion(req.LoginUID, version)
 		if err != nil {
 			co.Error("设置设备最近会话最大版本号失败！", zap.Error(err))
 			c.ResponseError(errors.New("设置设备最近会话最大版本号失败！"))
 			return
 		}
 	}
+	err = co.ctx.GetRedisConn().Del(co.syncConversationResultKey(req.LoginUID, req.DeviceUUID))
+	if err != nil {
+		co.Error("删除最近会话同步结果失败！", zap.Error(err))
+		c.ResponseError(errors.New("删除最近会话同步结果失败！"))
+		return
+	}
 
 	c.ResponseOK()
 }
 
+// 最近会话同步结果的缓存key（按用户和设备）
+func (co *Conversation) syncConversationResultKey(uid string, deviceUUID string) string {
+	return fmt.Sprintf("syncConversationResult:%s-%s", uid, deviceUUID)
+}
+
+// 缓存最近会话同步结果，版本号只增不减
+func (co *Conversation) setSyncConversationResult(uid string, deviceUUID string, version int64) error {
+	key := co.syncConversationResultKey(uid, deviceUUID)
+	cacheVersion, err := co.getSyncConversationVersion(uid, deviceUUID)
+	if err != nil {
+		return err
+	}
+	if cacheVersion < version {
+		if err = co.ctx.GetRedisConn().Hset(key, "version", strconv.FormatInt(version, 10)); err != nil {
+			return err
+		}
+	}
+	return co.ctx.GetRedisConn().Expire(key, syncConversationResultExpire)
+}
+
+func (co *Conversation) getSyncConversationVersion(uid string, deviceUUID string) (int64, error) {
+	versionStr, err := co.ctx.GetRedisConn().Hget(co.syncConversationResultKey(uid, deviceUUID), "version")
+	if err != nil {
+		return 0, err
+	}
+	if versionStr == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(versionStr, 10, 64)
+}
+
 func (co *Conversation) getDeviceConversationMaxVersion(uid string, deviceUUID string) (int64, error) {
 	versionStr, err := co.ctx.GetRedisConn().GetString(fmt.Sprintf("deviceMaxVersion:%s-%s", uid, deviceUUID))
 	if err != nil {
@@ -355,9 +467,24 @@ type SyncUserConversationResp struct {
 	Version         int64                  `json:"version,omitempty"`  // 数据版本
 	Recents         []*MsgSyncResp         `json:"recents,omitempty"`  // 最近N条消息
 	Extra           *conversationExtraResp `json:"extra,omitempty"`    // 扩展
+	Top             int                    `json:"top,omitempty"`      // 是否置顶
+	Mute            int                    `json:"mute,omitempty"`     // 是否免打扰
+	Hide            int                    `json:"hide,omitempty"`     // 是否从最近会话列表隐藏
+	Remark          string                 `json:"remark,omitempty"`   // 备注
+}
+
+// 合并用户的频道设置，免打扰的会话不显示红点（悟空IM仍会累计未读数）
+func (s *SyncUserConversationResp) setChannelSetting(setting *channelSettingModel) {
+	s.Top = setting.Top
+	s.Mute = setting.Mute
+	s.Hide = setting.Hide
+	s.Remark = setting.Remark
+	if setting.Mute == 1 {
+		s.Unread = 0
+	}
 }
 
-func newSyncUserConversationResp(resp *config.SyncUserConversationResp, loginUID string, messageExtraDB *messageExtraDB, messageUserExtraDB *messageUserExtraDB, channelOffsetMessageSeq uint32) *SyncUserConversationResp {
+func newSyncUserConversationResp(resp *config.SyncUserConversationResp, loginUID string, messageExtraDB *messageExtraDB, messageUserExtraDB *messageUserExtraDB, messageReactionDB *messageReactionDB, channelOffsetMessageSeq uint32) *SyncUserConversationResp {
 	recents := make([]*MsgSyncResp, 0, len(resp.Recents))
 	lastClientMsgNo := "" // 最新未被删除的消息的clientMsgNo
 	if len(resp.Recents) > 0 {
@@ -390,13 +517,16 @@ func newSyncUserConversationResp(resp *config.SyncUserConversationResp, loginUID
 			}
 		}
 
+		// 消息回应
+		reactionMap := queryReactionMap(messageReactionDB, messageIDs)
+
 		for _, message := range resp.Recents {
 
 			messageIDStr := strconv.FormatInt(message.MessageID, 10)
 			messageExtra := messageExtraMap[messageIDStr]
 			messageUserExtra := messageUserExtraMap[messageIDStr]
 			msgResp := &MsgSyncResp{}
-			msgResp.from(message, loginUID, messageExtra, messageUserExtra, channelOffsetMessageSeq)
+			msgResp.from(message, loginUID, messageExtra, messageUserExtra, reactionMap[messageIDStr], channelOffsetMessageSeq)
 			recents = append(recents, msgResp)
 
 			if lastClientMsgNo == "" && msgResp.IsDeleted == 0 {
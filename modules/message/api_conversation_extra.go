@@ -0,0 +1,119 @@
+package message
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/common"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/wkhttp"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/base"
+	"go.uber.org/zap"
+)
+
+// 更新最近会话扩展（草稿、浏览位置等）
+func (co *Conversation) updateExtra(c *wkhttp.Context) {
+	channelID := c.Param("channel_id")
+	channelTypeI64, _ := strconv.ParseInt(c.Param("channel_type"), 10, 64)
+	var req struct {
+		LoginUID       string `json:"login_uid"`
+		BrowseTo       uint32 `json:"browse_to"`
+		KeepMessageSeq uint32 `json:"keep_message_seq"`
+		KeepOffsetY    int    `json:"keep_offset_y"`
+		Draft          string `json:"draft"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		co.Error("数据格式有误！", zap.Error(err))
+		c.ResponseError(errors.New("数据格式有误！"))
+		return
+	}
+	if strings.TrimSpace(req.LoginUID) == "" {
+		c.ResponseError(errors.New("登录用户ID不能为空"))
+		return
+	}
+	if strings.TrimSpace(channelID) == "" || channelTypeI64 == 0 {
+		c.ResponseError(errors.New("频道ID和频道类型不能为空！"))
+		return
+	}
+	version := time.Now().UnixNano() / int64(time.Millisecond)
+	err := co.conversationExtraDB.insertOrUpdate(&conversationExtraModel{
+		UID:            req.LoginUID,
+		ChannelID:      channelID,
+		ChannelType:    uint8(channelTypeI64),
+		BrowseTo:       req.BrowseTo,
+		KeepMessageSeq: req.KeepMessageSeq,
+		KeepOffsetY:    req.KeepOffsetY,
+		Draft:          req.Draft,
+		Version:        version,
+	})
+	if err != nil {
+		co.Error("更新最近会话扩展失败！", zap.Error(err))
+		c.ResponseError(errors.New("更新最近会话扩展失败！"))
+		return
+	}
+	// 通知自己的其他设备同步最近会话扩展
+	err = base.SendCMD(config.MsgCMDReq{
+		NoPersist:   true,
+		ChannelID:   req.LoginUID,
+		ChannelType: common.ChannelTypePerson.Uint8(),
+		CMD:         common.CMDSyncConversationExtra,
+	})
+	if err != nil {
+		co.Error("命令发送失败！", zap.String("cmd", common.CMDSyncConversationExtra))
+		c.ResponseError(errors.New("命令发送失败！"))
+		return
+	}
+	c.Response(map[string]interface{}{
+		"version": version,
+	})
+}
+
+// 同步最近会话扩展
+func (co *Conversation) syncExtra(c *wkhttp.Context) {
+	var req struct {
+		LoginUID string `json:"login_uid"`
+		Version  int64  `json:"version"`
+		Limit    int    `json:"limit"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		co.Error("数据格式有误！", zap.Error(err))
+		c.ResponseError(errors.New("数据格式有误！"))
+		return
+	}
+	if strings.TrimSpace(req.LoginUID) == "" {
+		c.ResponseError(errors.New("登录用户ID不能为空"))
+		return
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+	models, err := co.conversationExtraDB.sync(req.LoginUID, req.Version, uint64(limit))
+	if err != nil {
+		co.Error("同步最近会话扩展失败！", zap.Error(err))
+		c.ResponseError(errors.New("同步最近会话扩展失败！"))
+		return
+	}
+	resps := make([]*conversationExtraResp, 0, len(models))
+	for _, model := range models {
+		resps = append(resps, newConversationExtraResp(model))
+	}
+	c.Response(resps)
+}
+
+func newConversationExtraResp(m *conversationExtraModel) *conversationExtraResp {
+	return &conversationExtraResp{
+		ChannelID:      m.ChannelID,
+		ChannelType:    m.ChannelType,
+		BrowseTo:       m.BrowseTo,
+		KeepMessageSeq: m.KeepMessageSeq,
+		KeepOffsetY:    m.KeepOffsetY,
+		Draft:          m.Draft,
+		Version:        m.Version,
+	}
+}
@@ -0,0 +1,157 @@
+package message
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/common"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/util"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/wkhttp"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/base"
+	"github.com/gocraft/dbr/v2"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// 编辑消息
+func (m *Message) edit(c *wkhttp.Context) {
+	var req *editReq
+	if err := c.BindJSON(&req); err != nil {
+		m.Error("数据格式有误！", zap.Error(err))
+		c.ResponseError(errors.New("数据格式有误！"))
+		return
+	}
+	if err := req.check(); err != nil {
+		c.ResponseError(err)
+		return
+	}
+	fakeChannelID := req.ChannelID
+	if req.ChannelType == common.ChannelTypePerson.Uint8() {
+		fakeChannelID = common.GetFakeChannelIDWith(req.ChannelID, req.LoginUID)
+	}
+
+	// 只有消息发送者才能编辑
+	messageResp, err := base.IM().GetMessagesWithSeqs(fakeChannelID, req.ChannelType, "", []uint32{req.MessageSeq})
+	if err != nil {
+		m.Error("查询消息错误", zap.Error(err))
+		c.ResponseError(errors.New("查询消息错误"))
+		return
+	}
+	var message *config.MessageResp
+	if messageResp != nil {
+		for _, msg := range messageResp.Messages {
+			if fmt.Sprintf("%d", msg.MessageID) == req.MessageID {
+				message = msg
+				break
+			}
+		}
+	}
+	if message == nil {
+		c.ResponseError(errors.New("消息不存在！"))
+		return
+	}
+	if message.FromUID != req.LoginUID {
+		c.ResponseError(errors.New("只能编辑自己发送的消息！"))
+		return
+	}
+
+	messageExtr, err := m.messageExtraDB.queryWithMessageID(req.MessageID)
+	if err != nil {
+		m.Error("查询消息扩展错误", zap.Error(err))
+		c.ResponseError(errors.New("查询消息扩展错误"))
+		return
+	}
+	contentEditHash := util.MD5(req.ContentEdit)
+	if messageExtr != nil && messageExtr.ContentEditHash == contentEditHash {
+		// 内容没有变化
+		c.ResponseOK()
+		return
+	}
+	version := time.Now().Unix()
+	editedAt := int(time.Now().Unix())
+	if messageExtr == nil {
+		err = m.messageExtraDB.insert(&messageExtraModel{
+			MessageID:       req.MessageID,
+			MessageSeq:      req.MessageSeq,
+			FromUID:         req.LoginUID,
+			ChannelID:       fakeChannelID,
+			ChannelType:     req.ChannelType,
+			ContentEdit:     dbr.NewNullString(req.ContentEdit),
+			ContentEditHash: contentEditHash,
+			EditedAt:        editedAt,
+			Version:         version,
+		})
+		if err != nil {
+			m.Error("新增消息扩展数据失败！", zap.Error(err), zap.String("messageID", req.MessageID), zap.String("channelID", fakeChannelID))
+			c.ResponseError(errors.New("编辑消息失败！"))
+			return
+		}
+	} else {
+		messageExtr.ContentEdit = dbr.NewNullString(req.ContentEdit)
+		messageExtr.ContentEditHash = contentEditHash
+		messageExtr.EditedAt = editedAt
+		messageExtr.Version = version
+		err = m.messageExtraDB.updateContentEdit(messageExtr)
+		if err != nil {
+			m.Error("更新消息扩展数据失败！", zap.Error(err), zap.String("messageID", req.MessageID), zap.String("channelID", fakeChannelID))
+			c.ResponseError(errors.New("编辑消息失败！"))
+			return
+		}
+	}
+
+	// 通知频道内成员同步消息扩展
+	err = base.SendCMD(config.MsgCMDReq{
+		NoPersist:   true,
+		FromUID:     req.LoginUID,
+		ChannelID:   req.ChannelID,
+		ChannelType: req.ChannelType,
+		CMD:         common.CMDSyncMessageExtra,
+		Param: map[string]interface{}{
+			"channel_id":   req.ChannelID,
+			"channel_type": req.ChannelType,
+		},
+	})
+	if err != nil {
+		m.Error("发送同步消息扩展cmd失败！", zap.Error(err))
+		c.ResponseError(errors.New("发送同步消息扩展cmd失败！"))
+		return
+	}
+	c.ResponseOK()
+}
+
+type editReq struct {
+	LoginUID    string `json:"login_uid"`
+	MessageID   string `json:"message_id"`
+	MessageSeq  uint32 `json:"message_seq"`
+	ChannelID   string `json:"channel_id"`
+	ChannelType uint8  `json:"channel_type"`
+	ContentEdit string `json:"content_edit"` // 编辑后的正文（json格式）
+}
+
+func (e *editReq) check() error {
+	if strings.TrimSpace(e.LoginUID) == "" {
+		return errors.New("uid不能为空")
+	}
+	if strings.TrimSpace(e.MessageID) == "" {
+		return errors.New("消息ID不能为空！")
+	}
+	if e.MessageSeq == 0 {
+		return errors.New("消息序号不能为空！")
+	}
+	if strings.TrimSpace(e.ChannelID) == "" {
+		return errors.New("频道ID不能为空！")
+	}
+	if e.ChannelType == 0 {
+		return errors.New("频道类型不能为空！")
+	}
+	if strings.TrimSpace(e.ContentEdit) == "" {
+		return errors.New("编辑内容不能为空！")
+	}
+	var contentEditMap map[string]interface{}
+	if err := util.ReadJsonByByte([]byte(e.ContentEdit), &contentEditMap); err != nil {
+		return errors.New("编辑内容必须为json格式！")
+	}
+	return nil
+}
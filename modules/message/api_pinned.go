@@ -0,0 +1,238 @@
+package message
+
+import (
+	"strings"
+	"time"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/common"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/wkhttp"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/base"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// 置顶消息
+func (m *Message) pin(c *wkhttp.Context) {
+	m.updatePinned(c, true)
+}
+
+// 取消置顶消息
+func (m *Message) unpin(c *wkhttp.Context) {
+	m.updatePinned(c, false)
+}
+
+func (m *Message) updatePinned(c *wkhttp.Context, pinned bool) {
+	var req *pinReq
+	if err := c.BindJSON(&req); err != nil {
+		m.Error("数据格式有误！", zap.Error(err))
+		c.ResponseError(errors.New("数据格式有误！"))
+		return
+	}
+	if err := req.check(); err != nil {
+		c.ResponseError(err)
+		return
+	}
+	fakeChannelID := req.ChannelID
+	if req.ChannelType == common.ChannelTypePerson.Uint8() {
+		fakeChannelID = common.GetFakeChannelIDWith(req.ChannelID, req.LoginUID)
+	}
+	isPinned := 0
+	isDeleted := 1
+	if pinned {
+		isPinned = 1
+		isDeleted = 0
+	}
+	// 版本号和置顶在同一事务内写入，同一频道的置顶按版本号顺序提交
+	tx, err := m.ctx.DB().Begin()
+	if err != nil {
+		m.Error("开启事务失败！", zap.Error(err))
+		c.ResponseError(errors.New("开启事务失败！"))
+		return
+	}
+	defer tx.RollbackUnlessCommitted()
+	pinnedVersion, err := m.channelSeqDB.nextWithTx(tx, fakeChannelID, req.ChannelType, channelSeqFlagPinned)
+	if err != nil {
+		m.Error("获取置顶版本号失败！", zap.Error(err))
+		c.ResponseError(errors.New("获取置顶版本号失败！"))
+		return
+	}
+	err = m.pinnedMessageDB.insertOrUpdateWithTx(tx, &pinnedMessageModel{
+		MessageID:   req.MessageID,
+		MessageSeq:  req.MessageSeq,
+		ChannelID:   fakeChannelID,
+		ChannelType: req.ChannelType,
+		Operator:    req.LoginUID,
+		IsDeleted:   isDeleted,
+		Version:     pinnedVersion,
+	})
+	if err != nil {
+		m.Error("更新置顶消息失败！", zap.Error(err), zap.String("messageID", req.MessageID))
+		c.ResponseError(errors.New("更新置顶消息失败！"))
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		m.Error("提交事务失败！", zap.Error(err))
+		c.ResponseError(errors.New("提交事务失败！"))
+		return
+	}
+
+	// 更新消息扩展，让客户端通过/extra/sync获取到置顶状态
+	messageExtr, err := m.messageExtraDB.queryWithMessageID(req.MessageID)
+	if err != nil {
+		m.Error("查询消息扩展错误", zap.Error(err))
+		c.ResponseError(errors.New("查询消息扩展错误"))
+		return
+	}
+	version := time.Now().Unix()
+	if messageExtr == nil {
+		err = m.messageExtraDB.insert(&messageExtraModel{
+			MessageID:   req.MessageID,
+			MessageSeq:  req.MessageSeq,
+			ChannelID:   fakeChannelID,
+			ChannelType: req.ChannelType,
+			IsPinned:    isPinned,
+			Version:     version,
+		})
+	} else {
+		err = m.messageExtraDB.updatePinned(req.MessageID, isPinned, version)
+	}
+	if err != nil {
+		m.Error("更新消息扩展数据失败！", zap.Error(err), zap.String("messageID", req.MessageID), zap.String("channelID", fakeChannelID))
+		c.ResponseError(errors.New("更新消息扩展数据失败！"))
+		return
+	}
+
+	err = base.SendCMD(config.MsgCMDReq{
+		NoPersist:   true,
+		FromUID:     req.LoginUID,
+		ChannelID:   req.ChannelID,
+		ChannelType: req.ChannelType,
+		CMD:         common.CMDSyncPinnedMessage,
+		Param: map[string]interface{}{
+			"channel_id":   req.ChannelID,
+			"channel_type": req.ChannelType,
+		},
+	})
+	if err != nil {
+		m.Error("发送同步置顶消息cmd失败！", zap.Error(err))
+		c.ResponseError(errors.New("发送同步置顶消息cmd失败！"))
+		return
+	}
+	c.ResponseOK()
+}
+
+// 同步置顶消息
+func (m *Message) syncPinnedMessage(c *wkhttp.Context) {
+	var req struct {
+		LoginUID    string `json:"login_uid"`
+		ChannelID   string `json:"channel_id"`
+		ChannelType uint8  `json:"channel_type"`
+		Version     int64  `json:"version"`
+		Limit       int    `json:"limit"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.ResponseErrorf("数据格式有误！", err)
+		return
+	}
+	if strings.TrimSpace(req.ChannelID) == "" {
+		c.ResponseError(errors.New("频道ID不能为空！"))
+		return
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+	fakeChannelID := req.ChannelID
+	if req.ChannelType == common.ChannelTypePerson.Uint8() {
+		fakeChannelID = common.GetFakeChannelIDWith(req.LoginUID, req.ChannelID)
+	}
+	pinnedModels, err := m.pinnedMessageDB.sync(req.Version, fakeChannelID, req.ChannelType, uint64(limit))
+	if err != nil {
+		c.ResponseErrorf("同步置顶消息失败！", err)
+		return
+	}
+	pinnedResps := make([]*pinnedMessageResp, 0, len(pinnedModels))
+	seqs := make([]uint32, 0, len(pinnedModels))
+	for _, pinnedModel := range pinnedModels {
+		pinnedResps = append(pinnedResps, newPinnedMessageResp(pinnedModel))
+		if pinnedModel.IsDeleted == 0 {
+			seqs = append(seqs, pinnedModel.MessageSeq)
+		}
+	}
+	messages := make([]*MsgSyncResp, 0, len(seqs))
+	if len(seqs) > 0 {
+		messageResp, err := base.IM().GetMessagesWithSeqs(fakeChannelID, req.ChannelType, "", seqs)
+		if err != nil {
+			m.Error("查询置顶的消息错误", zap.Error(err))
+			c.ResponseError(errors.New("查询置顶的消息错误"))
+			return
+		}
+		if messageResp != nil {
+			messages = newSyncChannelMessageResp(messageResp, req.LoginUID, m.messageExtraDB, m.messageUserExtraDB, m.messageReactionDB, 0).Messages
+		}
+	}
+	c.Response(&syncPinnedMessageResp{
+		PinnedMessages: pinnedResps,
+		Messages:       messages,
+	})
+}
+
+type pinReq struct {
+	LoginUID    string `json:"login_uid"`
+	MessageID   string `json:"message_id"`
+	MessageSeq  uint32 `json:"message_seq"`
+	ChannelID   string `json:"channel_id"`
+	ChannelType uint8  `json:"channel_type"`
+}
+
+func (p *pinReq) check() error {
+	if strings.TrimSpace(p.LoginUID) == "" {
+		return errors.New("uid不能为空")
+	}
+	if strings.TrimSpace(p.MessageID) == "" {
+		return errors.New("消息ID不能为空！")
+	}
+	if p.MessageSeq == 0 {
+		return errors.New("消息序号不能为空！")
+	}
+	if strings.TrimSpace(p.ChannelID) == "" {
+		return errors.New("频道ID不能为空！")
+	}
+	if p.ChannelType == 0 {
+		return errors.New("频道类型不能为空！")
+	}
+	return nil
+}
+
+type syncPinnedMessageResp struct {
+	PinnedMessages []*pinnedMessageResp `json:"pinned_messages"` // 置顶记录
+	Messages       []*MsgSyncResp       `json:"messages"`        // 置顶的消息
+}
+
+type pinnedMessageResp struct {
+	MessageID   string `json:"message_id"`
+	MessageSeq  uint32 `json:"message_seq"`
+	ChannelID   string `json:"channel_id"`
+	ChannelType uint8  `json:"channel_type"`
+	Operator    string `json:"operator"`
+	IsDeleted   int    `json:"is_deleted"`
+	Version     int64  `json:"version"`
+	CreatedAt   string `json:"created_at"`
+}
+
+func newPinnedMessageResp(m *pinnedMessageModel) *pinnedMessageResp {
+	return &pinnedMessageResp{
+		MessageID:   m.MessageID,
+		MessageSeq:  m.MessageSeq,
+		ChannelID:   m.ChannelID,
+		ChannelType: m.ChannelType,
+		Operator:    m.Operator,
+		IsDeleted:   m.IsDeleted,
+		Version:     m.Version,
+		CreatedAt:   m.CreatedAt.String(),
+	}
+}
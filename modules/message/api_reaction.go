@@ -0,0 +1,203 @@
+package message
+
+import (
+	"strings"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/common"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/log"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/wkhttp"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/base"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// 添加回应
+func (m *Message) reactionAdd(c *wkhttp.Context) {
+	m.updateReaction(c, 0)
+}
+
+// 取消回应
+func (m *Message) reactionRemove(c *wkhttp.Context) {
+	m.updateReaction(c, 1)
+}
+
+func (m *Message) updateReaction(c *wkhttp.Context, isDeleted int) {
+	var req *reactionReq
+	if err := c.BindJSON(&req); err != nil {
+		m.Error("数据格式有误！", zap.Error(err))
+		c.ResponseError(errors.New("数据格式有误！"))
+		return
+	}
+	if err := req.check(); err != nil {
+		c.ResponseError(err)
+		return
+	}
+	fakeChannelID := req.ChannelID
+	if req.ChannelType == common.ChannelTypePerson.Uint8() {
+		fakeChannelID = common.GetFakeChannelIDWith(req.ChannelID, req.LoginUID)
+	}
+	// 序号和回应在同一事务内写入，同一频道的回应按序号顺序提交
+	tx, err := m.ctx.DB().Begin()
+	if err != nil {
+		m.Error("开启事务失败！", zap.Error(err))
+		c.ResponseError(errors.New("开启事务失败！"))
+		return
+	}
+	defer tx.RollbackUnlessCommitted()
+	seq, err := m.channelSeqDB.nextWithTx(tx, fakeChannelID, req.ChannelType, channelSeqFlagReaction)
+	if err != nil {
+		m.Error("获取回应序号失败！", zap.Error(err))
+		c.ResponseError(errors.New("获取回应序号失败！"))
+		return
+	}
+	err = m.messageReactionDB.insertOrUpdateWithTx(tx, &messageReactionModel{
+		UID:         req.LoginUID,
+		MessageID:   req.MessageID,
+		ChannelID:   fakeChannelID,
+		ChannelType: req.ChannelType,
+		Emoji:       req.Emoji,
+		Seq:         seq,
+		IsDeleted:   isDeleted,
+	})
+	if err != nil {
+		m.Error("更新消息回应失败！", zap.Error(err), zap.String("messageID", req.MessageID))
+		c.ResponseError(errors.New("更新消息回应失败！"))
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		m.Error("提交事务失败！", zap.Error(err))
+		c.ResponseError(errors.New("提交事务失败！"))
+		return
+	}
+	err = base.SendCMD(config.MsgCMDReq{
+		NoPersist:   true,
+		FromUID:     req.LoginUID,
+		ChannelID:   req.ChannelID,
+		ChannelType: req.ChannelType,
+		CMD:         common.CMDSyncMessageReaction,
+		Param: map[string]interface{}{
+			"channel_id":   req.ChannelID,
+			"channel_type": req.ChannelType,
+		},
+	})
+	if err != nil {
+		m.Error("发送同步消息回应cmd失败！", zap.Error(err))
+		c.ResponseError(errors.New("发送同步消息回应cmd失败！"))
+		return
+	}
+	c.ResponseOK()
+}
+
+// 同步消息回应
+func (m *Message) syncReaction(c *wkhttp.Context) {
+	var req struct {
+		LoginUID    string `json:"login_uid"`
+		ChannelID   string `json:"channel_id"`
+		ChannelType uint8  `json:"channel_type"`
+		Seq         int64  `json:"seq"`   // 客户端最大的回应序号
+		Limit       int    `json:"limit"` // 数据限制
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.ResponseErrorf("数据格式有误！", err)
+		return
+	}
+	if strings.TrimSpace(req.ChannelID) == "" {
+		c.ResponseError(errors.New("频道ID不能为空！"))
+		return
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	if limit > 10000 {
+		limit = 10000
+	}
+	fakeChannelID := req.ChannelID
+	if req.ChannelType == common.ChannelTypePerson.Uint8() {
+		fakeChannelID = common.GetFakeChannelIDWith(req.LoginUID, req.ChannelID)
+	}
+	models, err := m.messageReactionDB.sync(fakeChannelID, req.ChannelType, req.Seq, uint64(limit))
+	if err != nil {
+		c.ResponseErrorf("同步消息回应失败！", err)
+		return
+	}
+	resps := make([]*reactionResp, 0, len(models))
+	for _, model := range models {
+		resps = append(resps, &reactionResp{
+			MessageID:   model.MessageID,
+			ChannelID:   req.ChannelID,
+			ChannelType: req.ChannelType,
+			reactionSimpleResp: reactionSimpleResp{
+				UID:       model.UID,
+				Name:      model.Name,
+				Seq:       model.Seq,
+				IsDeleted: model.IsDeleted,
+				Emoji:     model.Emoji,
+				CreatedAt: model.CreatedAt.String(),
+			},
+		})
+	}
+	c.Response(resps)
+}
+
+type reactionReq struct {
+	LoginUID    string `json:"login_uid"`
+	MessageID   string `json:"message_id"`
+	ChannelID   string `json:"channel_id"`
+	ChannelType uint8  `json:"channel_type"`
+	Emoji       string `json:"emoji"` // 回应的表情
+}
+
+func (r *reactionReq) check() error {
+	if strings.TrimSpace(r.LoginUID) == "" {
+		return errors.New("uid不能为空")
+	}
+	if strings.TrimSpace(r.MessageID) == "" {
+		return errors.New("消息ID不能为空！")
+	}
+	if strings.TrimSpace(r.ChannelID) == "" {
+		return errors.New("频道ID不能为空！")
+	}
+	if r.ChannelType == 0 {
+		return errors.New("频道类型不能为空！")
+	}
+	if strings.TrimSpace(r.Emoji) == "" {
+		return errors.New("回应的表情不能为空！")
+	}
+	if len(r.Emoji) > 20 {
+		return errors.New("回应的表情格式有误！")
+	}
+	return nil
+}
+
+// 消息内的回应
+type reactionSimpleResp struct {
+	UID       string `json:"uid"`        // 回应用户uid
+	Name      string `json:"name"`       // 回应用户的名字
+	Seq       int64  `json:"seq"`        // 回应序号
+	IsDeleted int    `json:"is_deleted"` // 是否已取消
+	Emoji     string `json:"emoji"`      // 回应的表情
+	CreatedAt string `json:"created_at"`
+}
+
+type reactionResp struct {
+	MessageID   string `json:"message_id"`
+	ChannelID   string `json:"channel_id"`
+	ChannelType uint8  `json:"channel_type"`
+	reactionSimpleResp
+}
+
+// 查询消息的回应并按消息ID分组
+func queryReactionMap(messageReactionDB *messageReactionDB, messageIDs []string) map[string][]*messageReactionDetailModel {
+	reactionMap := map[string][]*messageReactionDetailModel{}
+	reactions, err := messageReactionDB.queryWithMessageIDs(messageIDs)
+	if err != nil {
+		log.Error("查询消息回应失败！", zap.Error(err))
+		return reactionMap
+	}
+	for _, reaction := range reactions {
+		reactionMap[reaction.MessageID] = append(reactionMap[reaction.MessageID], reaction)
+	}
+	return reactionMap
+}
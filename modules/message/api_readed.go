@@ -0,0 +1,222 @@
+package message
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/common"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/util"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/wkhttp"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/base"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// 消息已读
+func (m *Message) readed(c *wkhttp.Context) {
+	var req *readedReq
+	if err := c.BindJSON(&req); err != nil {
+		m.Error("数据格式有误！", zap.Error(err))
+		c.ResponseError(errors.New("数据格式有误！"))
+		return
+	}
+	if err := req.check(); err != nil {
+		c.ResponseError(err)
+		return
+	}
+	fakeChannelID := req.ChannelID
+	if req.ChannelType == common.ChannelTypePerson.Uint8() {
+		fakeChannelID = common.GetFakeChannelIDWith(req.ChannelID, req.LoginUID)
+	}
+	messageIDs := util.RemoveRepeatedElement(req.MessageIDs)
+	messageMap, err := m.getChannelMessages(req.LoginUID, fakeChannelID, req.ChannelType, messageIDs)
+	if err != nil {
+		c.ResponseError(err)
+		return
+	}
+	messageUserExtraModels, err := m.messageUserExtraDB.queryWithMessageIDsAndUID(messageIDs, req.LoginUID)
+	if err != nil {
+		m.Error("查询用户消息扩展数据失败！", zap.Error(err))
+		c.ResponseError(errors.New("查询用户消息扩展数据失败！"))
+		return
+	}
+	readedMap := map[string]bool{}
+	for _, messageUserExtraM := range messageUserExtraModels {
+		if messageUserExtraM.Readed == 1 {
+			readedMap[messageUserExtraM.MessageID] = true
+		}
+	}
+	now := time.Now().Unix()
+	changed := false
+	// 一次请求一个事务，只有由未读变为已读的消息才增加已读数量，防止多端并发重复计数
+	tx, err := m.ctx.DB().Begin()
+	if err != nil {
+		m.Error("开启事务失败！", zap.Error(err))
+		c.ResponseError(errors.New("开启事务失败！"))
+		return
+	}
+	defer tx.RollbackUnlessCommitted()
+	for _, messageID := range messageIDs {
+		message := messageMap[messageID]
+		// 自己发的消息不计入已读
+		if readedMap[messageID] || message.FromUID == req.LoginUID {
+			continue
+		}
+		readedChanged, err := m.messageUserExtraDB.insertOrUpdateReadedWithTx(tx, &messageUserExtraModel{
+			UID:         req.LoginUID,
+			MessageID:   messageID,
+			MessageSeq:  message.MessageSeq,
+			ChannelID:   fakeChannelID,
+			ChannelType: req.ChannelType,
+			ReadedAt:    now,
+		})
+		if err != nil {
+			m.Error("标记消息已读失败！", zap.Error(err), zap.String("messageID", messageID))
+			c.ResponseError(errors.New("标记消息已读失败！"))
+			return
+		}
+		if !readedChanged {
+			continue
+		}
+		err = m.messageExtraDB.incrReadedCountWithTx(tx, &messageExtraModel{
+			MessageID:   messageID,
+			MessageSeq:  message.MessageSeq,
+			FromUID:     message.FromUID,
+			ChannelID:   fakeChannelID,
+			ChannelType: req.ChannelType,
+			Version:     now,
+		})
+		if err != nil {
+			m.Error("更新消息已读数量失败！", zap.Error(err), zap.String("messageID", messageID))
+			c.ResponseError(errors.New("更新消息已读数量失败！"))
+			return
+		}
+		changed = true
+	}
+	if err := tx.Commit(); err != nil {
+		m.Error("提交事务失败！", zap.Error(err))
+		c.ResponseError(errors.New("提交事务失败！"))
+		return
+	}
+	if !changed {
+		c.ResponseOK()
+		return
+	}
+	// 通知频道内成员同步消息扩展（已读数量）
+	err = base.SendCMD(config.MsgCMDReq{
+		NoPersist:   true,
+		FromUID:     req.LoginUID,
+		ChannelID:   req.ChannelID,
+		ChannelType: req.ChannelType,
+		CMD:         common.CMDSyncMessageExtra,
+		Param: map[string]interface{}{
+			"channel_id":   req.ChannelID,
+			"channel_type": req.ChannelType,
+		},
+	})
+	if err != nil {
+		m.Error("发送同步消息扩展cmd失败！", zap.Error(err))
+		c.ResponseError(errors.New("发送同步消息扩展cmd失败！"))
+		return
+	}
+	c.ResponseOK()
+}
+
+// 查询频道内的消息，消息ID不存在或不属于该频道则返回错误
+func (m *Message) getChannelMessages(loginUID string, channelID string, channelType uint8, messageIDs []string) (map[string]*config.MessageResp, error) {
+	ids := make([]int64, 0, len(messageIDs))
+	for _, messageID := range messageIDs {
+		id, err := strconv.ParseInt(messageID, 10, 64)
+		if err != nil {
+			return nil, errors.New("消息ID有误！")
+		}
+		ids = append(ids, id)
+	}
+	messageResp, err := base.IM().SearchMessages(&config.MsgSearchReq{
+		LoginUID:    loginUID,
+		ChannelID:   channelID,
+		ChannelType: channelType,
+		MessageIds:  ids,
+	})
+	if err != nil {
+		m.Error("查询消息错误", zap.Error(err))
+		return nil, errors.New("查询消息错误")
+	}
+	messageMap := make(map[string]*config.MessageResp, len(messageIDs))
+	if messageResp != nil {
+		for _, message := range messageResp.Messages {
+			if message.ChannelID != channelID || message.ChannelType != channelType {
+				continue
+			}
+			messageMap[strconv.FormatInt(message.MessageID, 10)] = message
+		}
+	}
+	for _, messageID := range messageIDs {
+		if messageMap[messageID] == nil {
+			return nil, errors.New("消息不存在！")
+		}
+	}
+	return messageMap, nil
+}
+
+// 消息已读成员列表
+func (m *Message) receipt(c *wkhttp.Context) {
+	messageID := c.Param("message_id")
+	if strings.TrimSpace(messageID) == "" {
+		c.ResponseError(errors.New("消息ID不能为空！"))
+		return
+	}
+	models, err := m.messageUserExtraDB.queryReadedWithMessageID(messageID)
+	if err != nil {
+		m.Error("查询消息已读成员失败！", zap.Error(err))
+		c.ResponseError(errors.New("查询消息已读成员失败！"))
+		return
+	}
+	sort.Slice(models, func(i, j int) bool {
+		return models[i].ReadedAt > models[j].ReadedAt
+	})
+	resps := make([]*receiptResp, 0, len(models))
+	for _, model := range models {
+		resps = append(resps, &receiptResp{
+			UID:      model.UID,
+			Name:     model.Name,
+			ReadedAt: model.ReadedAt,
+		})
+	}
+	c.Response(resps)
+}
+
+type readedReq struct {
+	LoginUID    string   `json:"login_uid"`
+	ChannelID   string   `json:"channel_id"`
+	ChannelType uint8    `json:"channel_type"`
+	MessageIDs  []string `json:"message_ids"` // 已读的消息ID集合
+}
+
+func (r *readedReq) check() error {
+	if strings.TrimSpace(r.LoginUID) == "" {
+		return errors.New("uid不能为空")
+	}
+	if strings.TrimSpace(r.ChannelID) == "" {
+		return errors.New("频道ID不能为空！")
+	}
+	if r.ChannelType == 0 {
+		return errors.New("频道类型不能为空！")
+	}
+	if len(r.MessageIDs) == 0 {
+		return errors.New("消息ID不能为空！")
+	}
+	if len(r.MessageIDs) > 100 {
+		return errors.New("一次最多标记100条消息已读！")
+	}
+	return nil
+}
+
+type receiptResp struct {
+	UID      string `json:"uid"`
+	Name     string `json:"name"`
+	ReadedAt int64  `json:"readed_at"` // 已读时间
+}
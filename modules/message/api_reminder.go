@@ -0,0 +1,216 @@
+package message
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/common"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/wkhttp"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/base"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// 同步提醒项
+func (m *Message) syncReminder(c *wkhttp.Context) {
+	var req struct {
+		LoginUID string `json:"login_uid"`
+		Version  int64  `json:"version"`
+		Limit    int    `json:"limit"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.ResponseErrorf("数据格式有误！", err)
+		return
+	}
+	if strings.TrimSpace(req.LoginUID) == "" {
+		c.ResponseError(errors.New("登录用户ID不能为空"))
+		return
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+	groupNos, err := m.groupService.GetGroupNosWithUID(req.LoginUID)
+	if err != nil {
+		m.Error("查询用户的群失败！", zap.Error(err))
+		c.ResponseError(errors.New("查询用户的群失败！"))
+		return
+	}
+	models, err := m.remindersDB.sync(req.LoginUID, groupNos, req.Version, uint64(limit))
+	if err != nil {
+		m.Error("同步提醒项失败！", zap.Error(err))
+		c.ResponseError(errors.New("同步提醒项失败！"))
+		return
+	}
+	resps := make([]*reminderResp, 0, len(models))
+	for _, model := range models {
+		resps = append(resps, newReminderResp(model))
+	}
+	c.Response(resps)
+}
+
+// 提醒项已完成
+func (m *Message) reminderDone(c *wkhttp.Context) {
+	var req struct {
+		LoginUID string  `json:"login_uid"`
+		IDs      []int64 `json:"ids"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.ResponseErrorf("数据格式有误！", err)
+		return
+	}
+	if strings.TrimSpace(req.LoginUID) == "" {
+		c.ResponseError(errors.New("登录用户ID不能为空"))
+		return
+	}
+	if len(req.IDs) == 0 {
+		c.ResponseError(errors.New("提醒项ID不能为空！"))
+		return
+	}
+	groupNos, err := m.groupService.GetGroupNosWithUID(req.LoginUID)
+	if err != nil {
+		m.Error("查询用户的群失败！", zap.Error(err))
+		c.ResponseError(errors.New("查询用户的群失败！"))
+		return
+	}
+	err = m.remindersDB.done(req.IDs, req.LoginUID, groupNos, time.Now().UnixNano()/int64(time.Millisecond))
+	if err != nil {
+		m.Error("完成提醒项失败！", zap.Error(err))
+		c.ResponseError(errors.New("完成提醒项失败！"))
+		return
+	}
+	// 通知用户的其他设备同步提醒项
+	err = base.SendCMD(config.MsgCMDReq{
+		NoPersist:   true,
+		ChannelID:   req.LoginUID,
+		ChannelType: common.ChannelTypePerson.Uint8(),
+		CMD:         common.CMDSyncReminders,
+	})
+	if err != nil {
+		m.Error("发送同步提醒项cmd失败！", zap.Error(err))
+		c.ResponseError(errors.New("发送同步提醒项cmd失败！"))
+		return
+	}
+	c.ResponseOK()
+}
+
+// 处理新消息内的@，生成对应的提醒项
+func (m *Message) handleMentionMessages(messages []*config.MessageResp) {
+	version := time.Now().UnixNano() / int64(time.Millisecond)
+	models := make([]*remindersModel, 0)
+	for _, message := range messages {
+		if message.ChannelType != common.ChannelTypeGroup.Uint8() {
+			continue
+		}
+		payloadMap, err := message.GetPayloadMap()
+		if err != nil {
+			m.Warn("消息payload格式有误！", zap.Error(err), zap.Int64("messageID", message.MessageID))
+			continue
+		}
+		mentionMap, ok := payloadMap["mention"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		model := &remindersModel{
+			ChannelID:    message.ChannelID,
+			ChannelType:  message.ChannelType,
+			MessageID:    strconv.FormatInt(message.MessageID, 10),
+			MessageSeq:   message.MessageSeq,
+			ClientMsgNo:  message.ClientMsgNo,
+			ReminderType: ReminderTypeMentionMe,
+			Publisher:    message.FromUID,
+			Text:         "[有人@我]",
+			IsLocate:     1,
+			Version:      version,
+		}
+		if all, ok := mentionMap["all"].(json.Number); ok && all.String() == "1" {
+			models = append(models, model)
+			continue
+		}
+		uids, _ := mentionMap["uids"].([]interface{})
+		uidMap := make(map[string]bool, len(uids))
+		for _, uidObj := range uids {
+			uid, _ := uidObj.(string)
+			if strings.TrimSpace(uid) == "" || uid == message.FromUID || uidMap[uid] {
+				continue
+			}
+			uidMap[uid] = true
+			uidModel := *model
+			uidModel.UID = uid
+			models = append(models, &uidModel)
+		}
+	}
+	if len(models) == 0 {
+		return
+	}
+	err := m.remindersDB.inserts(models)
+	if err != nil {
+		m.Error("添加提醒项失败！", zap.Error(err))
+		return
+	}
+	channelMap := make(map[string]bool)
+	for _, model := range models {
+		if channelMap[model.ChannelID] {
+			continue
+		}
+		channelMap[model.ChannelID] = true
+		m.sendSyncRemindersCMD(model.ChannelID, model.ChannelType)
+	}
+}
+
+// 通知频道内成员同步提醒项
+func (m *Message) sendSyncRemindersCMD(channelID string, channelType uint8) {
+	err := base.SendCMD(config.MsgCMDReq{
+		NoPersist:   true,
+		ChannelID:   channelID,
+		ChannelType: channelType,
+		CMD:         common.CMDSyncReminders,
+	})
+	if err != nil {
+		m.Error("发送同步提醒项cmd失败！", zap.Error(err), zap.String("channelID", channelID))
+	}
+}
+
+type reminderResp struct {
+	ID           int64  `json:"id"`
+	ChannelID    string `json:"channel_id"`
+	ChannelType  uint8  `json:"channel_type"`
+	MessageID    string `json:"message_id"`
+	MessageSeq   uint32 `json:"message_seq"`
+	ClientMsgNo  string `json:"client_msg_no"`
+	ReminderType int    `json:"reminder_type"` // 提醒类型 1.有人@我
+	UID          string `json:"uid"`           // 提醒的用户，为空表示频道内所有成员
+	Publisher    string `json:"publisher"`     // 提醒发布者
+	Text         string `json:"text"`
+	Data         string `json:"data"`
+	IsLocate     int    `json:"is_locate"` // 是否需要定位
+	Version      int64  `json:"version"`
+	Done         int    `json:"done"`       // 当前用户是否已完成
+	IsDeleted    int    `json:"is_deleted"` // 是否已删除
+}
+
+func newReminderResp(m *reminderDetailModel) *reminderResp {
+	return &reminderResp{
+		ID:           m.Id,
+		ChannelID:    m.ChannelID,
+		ChannelType:  m.ChannelType,
+		MessageID:    m.MessageID,
+		MessageSeq:   m.MessageSeq,
+		ClientMsgNo:  m.ClientMsgNo,
+		ReminderType: m.ReminderType,
+		UID:          m.UID,
+		Publisher:    m.Publisher,
+		Text:         m.Text,
+		Data:         m.Data,
+		IsLocate:     m.IsLocate,
+		Version:      m.userVersion(),
+		Done:         m.Done,
+		IsDeleted:    m.IsDeleted,
+	}
+}
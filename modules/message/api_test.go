@@ -3,14 +3,17 @@ package message
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/common"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/util"
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/testutil"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/base"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -143,3 +146,344 @@ func TestSyncExtra(t *testing.T) {
 	fmt.Println(w.Body.String())
 	assert.Equal(t, true, strings.Contains(w.Body.String(), `"uid":`))
 }
+
+func TestSyncPinnedMessage(t *testing.T) {
+	_, ctx := testutil.NewTestServer()
+	m := New(ctx)
+	//清除数据
+	err := testutil.CleanAllTables(ctx)
+	assert.NoError(t, err)
+	err = m.pinnedMessageDB.insertOrUpdate(&pinnedMessageModel{
+		MessageID:   "1",
+		MessageSeq:  1,
+		ChannelID:   "g1",
+		ChannelType: common.ChannelTypeGroup.Uint8(),
+		Operator:    "sl",
+		Version:     1,
+	})
+	assert.NoError(t, err)
+	err = m.pinnedMessageDB.insertOrUpdate(&pinnedMessageModel{
+		MessageID:   "2",
+		MessageSeq:  2,
+		ChannelID:   "g1",
+		ChannelType: common.ChannelTypeGroup.Uint8(),
+		Operator:    "sl",
+		IsDeleted:   1,
+		Version:     2,
+	})
+	assert.NoError(t, err)
+
+	// 首次同步不返回已取消的置顶
+	models, err := m.pinnedMessageDB.sync(0, "g1", common.ChannelTypeGroup.Uint8(), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(models))
+
+	models, err = m.pinnedMessageDB.sync(1, "g1", common.ChannelTypeGroup.Uint8(), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(models))
+	assert.Equal(t, "2", models[0].MessageID)
+}
+
+func TestSyncReaction(t *testing.T) {
+	s, ctx := testutil.NewTestServer()
+	m := New(ctx)
+	//清除数据
+	err := testutil.CleanAllTables(ctx)
+	assert.NoError(t, err)
+	err = m.messageReactionDB.insertOrUpdate(&messageReactionModel{
+		UID:         "sl",
+		MessageID:   "1848341056756551680",
+		ChannelID:   "g1",
+		ChannelType: common.ChannelTypeGroup.Uint8(),
+		Emoji:       "👍",
+		Seq:         1,
+	})
+	assert.NoError(t, err)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/reaction/sync", bytes.NewReader([]byte(util.ToJson(map[string]interface{}{
+		"login_uid":    "sl",
+		"channel_id":   "g1",
+		"channel_type": common.ChannelTypeGroup.Uint8(),
+		"seq":          0,
+	}))))
+
+	s.GetRoute().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, true, strings.Contains(w.Body.String(), `"emoji":"👍"`))
+}
+
+func TestChannelSeq(t *testing.T) {
+	_, ctx := testutil.NewTestServer()
+	m := New(ctx)
+	//清除数据
+	err := testutil.CleanAllTables(ctx)
+	assert.NoError(t, err)
+	next := func(channelID string) int64 {
+		tx, err := ctx.DB().Begin()
+		assert.NoError(t, err)
+		defer tx.RollbackUnlessCommitted()
+		seq, err := m.channelSeqDB.nextWithTx(tx, channelID, common.ChannelTypeGroup.Uint8(), channelSeqFlagReaction)
+		assert.NoError(t, err)
+		assert.NoError(t, tx.Commit())
+		return seq
+	}
+	assert.Equal(t, int64(1), next("g1"))
+	assert.Equal(t, int64(2), next("g1"))
+	assert.Equal(t, int64(1), next("g2"))
+}
+
+func TestReadedReceipt(t *testing.T) {
+	s, ctx := testutil.NewTestServer()
+	m := New(ctx)
+	//清除数据
+	err := testutil.CleanAllTables(ctx)
+	assert.NoError(t, err)
+	// 模拟悟空IM，g1内只有sl发的消息1
+	im := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/messages" {
+			body, _ := io.ReadAll(r.Body)
+			if strings.Contains(string(body), `"channel_id":"g1"`) && strings.Contains(string(body), `"message_ids":[1]`) {
+				w.Write([]byte(`{"messages":[{"message_id":1,"message_seq":5,"from_uid":"sl","channel_id":"g1","channel_type":2}]}`))
+				return
+			}
+			w.Write([]byte(`{"messages":[]}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer im.Close()
+	cfg := config.New()
+	cfg.WuKongIM.APIURL = im.URL
+	base.SetupIM(cfg)
+	defer base.SetupIM(ctx.GetConfig())
+
+	readed := func(uid string, channelID string, messageID string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/message/readed", bytes.NewReader([]byte(util.ToJson(map[string]interface{}{
+			"login_uid":    uid,
+			"channel_id":   channelID,
+			"channel_type": common.ChannelTypeGroup.Uint8(),
+			"message_ids":  []string{messageID},
+		}))))
+		s.GetRoute().ServeHTTP(w, req)
+		return w.Code
+	}
+	// u1重复标记已读（例如多端），已读数量只增加一次；发送者自己不计入
+	for _, uid := range []string{"u1", "u1", "u2", "sl"} {
+		assert.Equal(t, http.StatusOK, readed(uid, "g1", "1"))
+	}
+	// 不存在或不属于该频道的消息
+	assert.Equal(t, http.StatusBadRequest, readed("u1", "g1", "2"))
+	assert.Equal(t, http.StatusBadRequest, readed("u1", "g2", "1"))
+
+	models, err := m.messageUserExtraDB.queryReadedWithMessageID("1")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(models))
+
+	extra, err := m.messageExtraDB.queryWithMessageID("1")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, extra.ReadedCount)
+	assert.Equal(t, uint32(5), extra.MessageSeq)
+
+	extra, err = m.messageExtraDB.queryWithMessageID("2")
+	assert.NoError(t, err)
+	assert.Nil(t, extra)
+}
+
+func TestSyncReminder(t *testing.T) {
+	_, ctx := testutil.NewTestServer()
+	m := New(ctx)
+	//清除数据
+	err := testutil.CleanAllTables(ctx)
+	assert.NoError(t, err)
+	err = m.remindersDB.inserts([]*remindersModel{
+		{ChannelID: "g1", ChannelType: common.ChannelTypeGroup.Uint8(), MessageID: "1", UID: "u1", Publisher: "sl", ReminderType: ReminderTypeMentionMe, Version: 1},
+		{ChannelID: "g1", ChannelType: common.ChannelTypeGroup.Uint8(), MessageID: "2", Publisher: "sl", ReminderType: ReminderTypeMentionMe, Version: 2},
+		{ChannelID: "g2", ChannelType: common.ChannelTypeGroup.Uint8(), MessageID: "3", Publisher: "sl", ReminderType: ReminderTypeMentionMe, Version: 3},
+	})
+	assert.NoError(t, err)
+
+	// 不在g2群内，不返回g2的@所有人
+	models, err := m.remindersDB.sync("u1", []string{"g1"}, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(models))
+
+	// g2的提醒项u1看不到，不能标记完成
+	err = m.remindersDB.done([]int64{models[1].Id, models[1].Id + 1}, "u1", []string{"g1"}, 10)
+	assert.NoError(t, err)
+	models, err = m.remindersDB.sync("u1", []string{"g1"}, 2, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(models))
+	assert.Equal(t, 1, models[0].Done)
+	assert.Equal(t, int64(2), models[0].Version)
+	assert.Equal(t, int64(10), models[0].userVersion())
+	var doneCount int
+	_, err = ctx.DB().Select("count(*)").From("reminder_done").Load(&doneCount)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, doneCount)
+
+	// 群内其他成员不受u1完成状态的影响
+	models, err = m.remindersDB.sync("u2", []string{"g1"}, 2, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(models))
+
+	err = m.remindersDB.deleteWithMessageIDs([]string{"1"}, 11)
+	assert.NoError(t, err)
+	models, err = m.remindersDB.sync("u1", []string{"g1"}, 10, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(models))
+	assert.Equal(t, 1, models[0].IsDeleted)
+}
+
+func TestMutualDelete(t *testing.T) {
+	_, ctx := testutil.NewTestServer()
+	m := New(ctx)
+	//清除数据
+	err := testutil.CleanAllTables(ctx)
+	assert.NoError(t, err)
+	fakeChannelID := common.GetFakeChannelIDWith("u1", "u2")
+	err = m.messageExtraDB.mutualDelete(&messageExtraModel{
+		MessageID:   "1",
+		MessageSeq:  1,
+		FromUID:     "u1",
+		ChannelID:   fakeChannelID,
+		ChannelType: common.ChannelTypePerson.Uint8(),
+		Version:     1,
+	})
+	assert.NoError(t, err)
+
+	models, err := m.messageExtraDB.sync(0, fakeChannelID, common.ChannelTypePerson.Uint8(), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(models))
+	assert.Equal(t, 1, newMessageExtraResp(models[0], nil).IsMutualDeleted)
+}
+
+func TestDeleteMsgBatch(t *testing.T) {
+	_, ctx := testutil.NewTestServer()
+	m := New(ctx)
+	//清除数据
+	err := testutil.CleanAllTables(ctx)
+	assert.NoError(t, err)
+	models := make([]*messageUserExtraModel, 0)
+	for i := 1; i <= 3; i++ {
+		models = append(models, &messageUserExtraModel{
+			MessageID:        fmt.Sprintf("%d", i),
+			MessageSeq:       uint32(i),
+			ChannelID:        "g1",
+			ChannelType:      common.ChannelTypeGroup.Uint8(),
+			MessageIsDeleted: 1,
+		})
+	}
+	err = m.messageUserExtraDB.insertOrUpdateDeletedBatch("u1", models)
+	assert.NoError(t, err)
+
+	userExtras, err := m.messageUserExtraDB.queryWithMessageIDsAndUID([]string{"1", "2", "3"}, "u1")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(userExtras))
+	for _, userExtra := range userExtras {
+		assert.Equal(t, 1, userExtra.MessageIsDeleted)
+	}
+}
+
+func TestChannelOffset(t *testing.T) {
+	s, ctx := testutil.NewTestServer()
+	//清除数据
+	err := testutil.CleanAllTables(ctx)
+	assert.NoError(t, err)
+	_, err = ctx.DB().InsertInto("group_member").Columns("group_no", "uid", "role").Values("g1", "u1", 0).Exec()
+	assert.NoError(t, err)
+	// 普通成员无权清除所有成员的消息
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/message/offset/channel", bytes.NewReader([]byte(util.ToJson(map[string]interface{}{
+		"login_uid":    "u1",
+		"channel_id":   "g1",
+		"channel_type": common.ChannelTypeGroup.Uint8(),
+		"message_seq":  10,
+	}))))
+	s.GetRoute().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.True(t, strings.Contains(w.Body.String(), "只有群主或管理员才能操作"))
+}
+
+func TestSyncConversationExtra(t *testing.T) {
+	_, ctx := testutil.NewTestServer()
+	co := NewConversation(ctx)
+	//清除数据
+	err := testutil.CleanAllTables(ctx)
+	assert.NoError(t, err)
+	err = co.conversationExtraDB.insertOrUpdate(&conversationExtraModel{
+		UID:         "u1",
+		ChannelID:   "g1",
+		ChannelType: common.ChannelTypeGroup.Uint8(),
+		BrowseTo:    10,
+		Draft:       "草稿",
+		Version:     1,
+	})
+	assert.NoError(t, err)
+	// 浏览位置只能前进
+	err = co.conversationExtraDB.insertOrUpdate(&conversationExtraModel{
+		UID:         "u1",
+		ChannelID:   "g1",
+		ChannelType: common.ChannelTypeGroup.Uint8(),
+		BrowseTo:    5,
+		Version:     2,
+	})
+	assert.NoError(t, err)
+
+	models, err := co.conversationExtraDB.sync("u1", 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(models))
+	assert.Equal(t, uint32(10), models[0].BrowseTo)
+	assert.Equal(t, "", models[0].Draft)
+}
+
+func TestSetChannelSettingMute(t *testing.T) {
+	resp := &SyncUserConversationResp{Unread: 3}
+	resp.setChannelSetting(&channelSettingModel{Top: 1})
+	assert.Equal(t, 3, resp.Unread)
+	assert.Equal(t, 1, resp.Top)
+
+	// 免打扰的会话不显示红点
+	resp.setChannelSetting(&channelSettingModel{Mute: 1})
+	assert.Equal(t, 0, resp.Unread)
+	assert.Equal(t, 1, resp.Mute)
+}
+
+func TestChannelSetting(t *testing.T) {
+	_, ctx := testutil.NewTestServer()
+	co := NewConversation(ctx)
+	//清除数据
+	err := testutil.CleanAllTables(ctx)
+	assert.NoError(t, err)
+	err = co.channelSettingDB.insertOrUpdate("u1", "g1", common.ChannelTypeGroup.Uint8(), map[string]interface{}{"top": 1, "remark": "群备注"}, 1)
+	assert.NoError(t, err)
+	// 只修改传入的字段
+	err = co.channelSettingDB.insertOrUpdate("u1", "g1", common.ChannelTypeGroup.Uint8(), map[string]interface{}{"mute": 1}, 2)
+	assert.NoError(t, err)
+
+	model, err := co.channelSettingDB.queryWithUIDAndChannel("u1", "g1", common.ChannelTypeGroup.Uint8())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, model.Top)
+	assert.Equal(t, 1, model.Mute)
+	assert.Equal(t, "群备注", model.Remark)
+	assert.Equal(t, int64(2), model.Version)
+}
+
+func TestSyncConversationResultCache(t *testing.T) {
+	_, ctx := testutil.NewTestServer()
+	co := NewConversation(ctx)
+	err := co.setSyncConversationResult("u1", "d1", 10)
+	assert.NoError(t, err)
+	// 版本号只增不减
+	err = co.setSyncConversationResult("u1", "d1", 5)
+	assert.NoError(t, err)
+
+	version, err := co.getSyncConversationVersion("u1", "d1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), version)
+
+	// 其他设备互不影响
+	version, err = co.getSyncConversationVersion("u1", "d2")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), version)
+}
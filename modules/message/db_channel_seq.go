@@ -0,0 +1,32 @@
+package message
+
+import (
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/gocraft/dbr/v2"
+)
+
+const (
+	channelSeqFlagReaction = "messageReaction" // 消息回应
+	channelSeqFlagPinned   = "pinnedMessage"   // 置顶消息
+)
+
+type channelSeqDB struct {
+	ctx     *config.Context
+	session *dbr.Session
+}
+
+func newChannelSeqDB(ctx *config.Context) *channelSeqDB {
+	return &channelSeqDB{
+		ctx:     ctx,
+		session: ctx.DB(),
+	}
+}
+
+// 获取频道内下一个序号（更新会锁住该行直到事务结束，同一频道的写入按序号顺序提交，增量同步不会漏数据）
+func (c *channelSeqDB) nextWithTx(tx *dbr.Tx, channelID string, channelType uint8, flag string) (int64, error) {
+	result, err := tx.InsertBySql("INSERT INTO channel_seq (channel_id,channel_type,flag,seq) VALUES (?,?,?,LAST_INSERT_ID(1)) ON DUPLICATE KEY UPDATE seq=LAST_INSERT_ID(seq+1)", channelID, channelType, flag).Exec()
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
@@ -0,0 +1,95 @@
+package message
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/common"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/db"
+	"github.com/gocraft/dbr/v2"
+)
+
+type channelSettingDB struct {
+	ctx     *config.Context
+	session *dbr.Session
+}
+
+func newChannelSettingDB(ctx *config.Context) *channelSettingDB {
+	return &channelSettingDB{
+		ctx:     ctx,
+		session: ctx.DB(),
+	}
+}
+
+// 新增或更新频道设置，settingMap只包含需要修改的字段
+func (c *channelSettingDB) insertOrUpdate(uid string, channelID string, channelType uint8, settingMap map[string]interface{}, version int64) error {
+	columns := []string{"uid", "channel_id", "channel_type", "version"}
+	placeholders := []string{"?", "?", "?", "?"}
+	updates := []string{"version=VALUES(version)"}
+	values := []interface{}{uid, channelID, channelType, version}
+	for column, value := range settingMap {
+		columns = append(columns, column)
+		placeholders = append(placeholders, "?")
+		updates = append(updates, fmt.Sprintf("%s=VALUES(%s)", column, column))
+		values = append(values, value)
+	}
+	sq := fmt.Sprintf("INSERT INTO channel_setting (%s) VALUES (%s) ON DUPLICATE KEY UPDATE %s", strings.Join(columns, ","), strings.Join(placeholders, ","), strings.Join(updates, ","))
+	_, err := c.session.InsertBySql(sq, values...).Exec()
+	return err
+}
+
+func (c *channelSettingDB) queryWithUIDAndChannel(uid string, channelID string, channelType uint8) (*channelSettingModel, error) {
+	var model *channelSettingModel
+	_, err := c.session.Select("*").From("channel_setting").Where("uid=? and channel_id=? and channel_type=?", uid, channelID, channelType).Load(&model)
+	return model, err
+}
+
+func (c *channelSettingDB) queryWithUIDAndChannelIDs(uid string, channelIDs []string) ([]*channelSettingModel, error) {
+	if len(channelIDs) == 0 {
+		return nil, nil
+	}
+	var models []*channelSettingModel
+	_, err := c.session.Select("*").From("channel_setting").Where("uid=? and channel_id in ?", uid, channelIDs).Load(&models)
+	return models, err
+}
+
+// 查询在指定频道设置了免打扰的用户
+func (c *channelSettingDB) queryMuteUIDs(uids []string, channelID string, channelType uint8) ([]string, error) {
+	if len(uids) == 0 {
+		return nil, nil
+	}
+	var muteUIDs []string
+	_, err := c.session.Select("uid").From("channel_setting").Where("uid in ? and channel_id=? and channel_type=? and mute=1", uids, channelID, channelType).Load(&muteUIDs)
+	return muteUIDs, err
+}
+
+// 频道有新消息时取消隐藏
+func (c *channelSettingDB) unhideWithChannel(channelID string, channelType uint8, version int64) error {
+	_, err := c.session.Update("channel_setting").SetMap(map[string]interface{}{
+		"hide":    0,
+		"version": version,
+	}).Where("channel_id=? and channel_type=? and hide=1", channelID, channelType).Exec()
+	return err
+}
+
+// 单聊有新消息时取消双方的隐藏
+func (c *channelSettingDB) unhideWithPerson(uid1 string, uid2 string, version int64) error {
+	_, err := c.session.Update("channel_setting").SetMap(map[string]interface{}{
+		"hide":    0,
+		"version": version,
+	}).Where("channel_type=? and hide=1 and ((uid=? and channel_id=?) or (uid=? and channel_id=?))", common.ChannelTypePerson.Uint8(), uid1, uid2, uid2, uid1).Exec()
+	return err
+}
+
+type channelSettingModel struct {
+	UID         string
+	ChannelID   string
+	ChannelType uint8
+	Top         int    // 是否置顶
+	Mute        int    // 是否免打扰
+	Hide        int    // 是否从最近会话列表隐藏
+	Remark      string // 备注
+	Version     int64  // 数据版本
+	db.BaseModel
+}
@@ -0,0 +1,51 @@
+package message
+
+import (
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/db"
+	"github.com/gocraft/dbr/v2"
+)
+
+type conversationExtraDB struct {
+	ctx     *config.Context
+	session *dbr.Session
+}
+
+func newConversationExtraDB(ctx *config.Context) *conversationExtraDB {
+	return &conversationExtraDB{
+		ctx:     ctx,
+		session: ctx.DB(),
+	}
+}
+
+func (c *conversationExtraDB) insertOrUpdate(m *conversationExtraModel) error {
+	_, err := c.session.InsertBySql("INSERT INTO conversation_extra (uid,channel_id,channel_type,browse_to,keep_message_seq,keep_offset_y,draft,version) VALUES (?,?,?,?,?,?,?,?) ON DUPLICATE KEY UPDATE browse_to=IF(browse_to<VALUES(browse_to),VALUES(browse_to),browse_to),keep_message_seq=VALUES(keep_message_seq),keep_offset_y=VALUES(keep_offset_y),draft=VALUES(draft),version=VALUES(version)", m.UID, m.ChannelID, m.ChannelType, m.BrowseTo, m.KeepMessageSeq, m.KeepOffsetY, m.Draft, m.Version).Exec()
+	return err
+}
+
+func (c *conversationExtraDB) queryWithUIDAndChannelIDs(uid string, channelIDs []string) ([]*conversationExtraModel, error) {
+	if len(channelIDs) == 0 {
+		return nil, nil
+	}
+	var models []*conversationExtraModel
+	_, err := c.session.Select("*").From("conversation_extra").Where("uid=? and channel_id in ?", uid, channelIDs).Load(&models)
+	return models, err
+}
+
+func (c *conversationExtraDB) sync(uid string, version int64, limit uint64) ([]*conversationExtraModel, error) {
+	var models []*conversationExtraModel
+	_, err := c.session.Select("*").From("conversation_extra").Where("uid=? and version>?", uid, version).OrderAsc("version").Limit(limit).Load(&models)
+	return models, err
+}
+
+type conversationExtraModel struct {
+	UID            string
+	ChannelID      string
+	ChannelType    uint8
+	BrowseTo       uint32 // 预览到的位置
+	KeepMessageSeq uint32 // 会话保持的位置
+	KeepOffsetY    int    // 会话保持的位置的偏移量
+	Draft          string // 草稿
+	Version        int64  // 数据版本
+	db.BaseModel
+}
@@ -36,6 +36,54 @@ func (m *messageExtraDB) update(md *messageExtraModel) error {
 	return err
 }
 
+// 批量标记消息撤回（消息扩展不存在则新增）
+func (m *messageExtraDB) revokeWithMessages(models []*messageExtraModel) error {
+	tx, err := m.session.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.RollbackUnlessCommitted()
+	for _, md := range models {
+		_, err = tx.InsertBySql("INSERT INTO message_extra (message_id,message_seq,channel_id,channel_type,from_uid,`revoke`,revoker,version) VALUES (?,?,?,?,?,1,?,?) ON DUPLICATE KEY UPDATE `revoke`=1,revoker=VALUES(revoker),version=VALUES(version)", md.MessageID, md.MessageSeq, md.ChannelID, md.ChannelType, md.FromUID, md.Revoker, md.Version).Exec()
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// 标记消息双向删除（消息扩展不存在则新增）
+func (m *messageExtraDB) mutualDelete(md *messageExtraModel) error {
+	_, err := m.session.InsertBySql("INSERT INTO message_extra (message_id,message_seq,channel_id,channel_type,from_uid,is_deleted,version) VALUES (?,?,?,?,?,1,?) ON DUPLICATE KEY UPDATE is_deleted=1,version=VALUES(version)", md.MessageID, md.MessageSeq, md.ChannelID, md.ChannelType, md.FromUID, md.Version).Exec()
+	return err
+}
+
+// 更新编辑后的正文
+func (m *messageExtraDB) updateContentEdit(md *messageExtraModel) error {
+	_, err := m.session.Update("message_extra").SetMap(map[string]interface{}{
+		"content_edit":      md.ContentEdit,
+		"content_edit_hash": md.ContentEditHash,
+		"edited_at":         md.EditedAt,
+		"version":           md.Version,
+	}).Where("message_id=?", md.MessageID).Exec()
+	return err
+}
+
+// 已读数量加1（消息扩展不存在则新增）
+func (m *messageExtraDB) incrReadedCountWithTx(tx *dbr.Tx, md *messageExtraModel) error {
+	_, err := tx.InsertBySql("INSERT INTO message_extra (message_id,message_seq,channel_id,channel_type,from_uid,readed_count,version) VALUES (?,?,?,?,?,1,?) ON DUPLICATE KEY UPDATE readed_count=readed_count+1,version=VALUES(version)", md.MessageID, md.MessageSeq, md.ChannelID, md.ChannelType, md.FromUID, md.Version).Exec()
+	return err
+}
+
+// 更新置顶状态
+func (m *messageExtraDB) updatePinned(messageID string, isPinned int, version int64) error {
+	_, err := m.session.Update("message_extra").SetMap(map[string]interface{}{
+		"is_pinned": isPinned,
+		"version":   version,
+	}).Where("message_id=?", messageID).Exec()
+	return err
+}
+
 func (m *messageExtraDB) queryWithMessageIDs(messageIDs []string) ([]*messageExtraModel, error) {
 	if len(messageIDs) <= 0 {
 		return nil, nil
@@ -92,6 +140,7 @@ type messageExtraModel struct {
 	ContentEditHash string
 	EditedAt        int // 编辑时间 时间戳（秒）
 	IsDeleted       int
+	IsPinned        int   // 是否置顶
 	Version         int64 // 数据版本
 	db.BaseModel
 }
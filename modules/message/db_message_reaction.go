@@ -0,0 +1,67 @@
+package message
+
+import (
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/db"
+	"github.com/gocraft/dbr/v2"
+)
+
+type messageReactionDB struct {
+	ctx     *config.Context
+	session *dbr.Session
+}
+
+func newMessageReactionDB(ctx *config.Context) *messageReactionDB {
+	return &messageReactionDB{
+		ctx:     ctx,
+		session: ctx.DB(),
+	}
+}
+
+// 添加或取消回应（取消只做删除标记，方便客户端增量同步）
+func (m *messageReactionDB) insertOrUpdate(md *messageReactionModel) error {
+	_, err := m.session.InsertBySql("INSERT INTO message_reaction (uid,message_id,channel_id,channel_type,emoji,seq,is_deleted) VALUES (?,?,?,?,?,?,?) ON DUPLICATE KEY UPDATE seq=VALUES(seq),is_deleted=VALUES(is_deleted)", md.UID, md.MessageID, md.ChannelID, md.ChannelType, md.Emoji, md.Seq, md.IsDeleted).Exec()
+	return err
+}
+
+func (m *messageReactionDB) insertOrUpdateWithTx(tx *dbr.Tx, md *messageReactionModel) error {
+	_, err := tx.InsertBySql("INSERT INTO message_reaction (uid,message_id,channel_id,channel_type,emoji,seq,is_deleted) VALUES (?,?,?,?,?,?,?) ON DUPLICATE KEY UPDATE seq=VALUES(seq),is_deleted=VALUES(is_deleted)", md.UID, md.MessageID, md.ChannelID, md.ChannelType, md.Emoji, md.Seq, md.IsDeleted).Exec()
+	return err
+}
+
+// 查询消息的回应
+func (m *messageReactionDB) queryWithMessageIDs(messageIDs []string) ([]*messageReactionDetailModel, error) {
+	if len(messageIDs) <= 0 {
+		return nil, nil
+	}
+	var models []*messageReactionDetailModel
+	_, err := m.detailSelect().Where("message_reaction.message_id in ? and message_reaction.is_deleted=0", messageIDs).OrderAsc("message_reaction.seq").Load(&models)
+	return models, err
+}
+
+// 同步频道内大于seq的回应
+func (m *messageReactionDB) sync(channelID string, channelType uint8, seq int64, limit uint64) ([]*messageReactionDetailModel, error) {
+	var models []*messageReactionDetailModel
+	_, err := m.detailSelect().Where("message_reaction.channel_id=? and message_reaction.channel_type=? and message_reaction.seq>?", channelID, channelType, seq).OrderAsc("message_reaction.seq").Limit(limit).Load(&models)
+	return models, err
+}
+
+func (m *messageReactionDB) detailSelect() *dbr.SelectStmt {
+	return m.session.Select("message_reaction.*,IFNULL(user.name,'') name").From("message_reaction").LeftJoin("user", "message_reaction.uid=user.uid")
+}
+
+type messageReactionModel struct {
+	UID         string
+	MessageID   string
+	ChannelID   string
+	ChannelType uint8
+	Emoji       string // 回应的表情
+	Seq         int64  // 回应序号
+	IsDeleted   int
+	db.BaseModel
+}
+
+type messageReactionDetailModel struct {
+	messageReactionModel
+	Name string // 回应用户的名字
+}
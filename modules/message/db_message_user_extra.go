@@ -3,6 +3,7 @@ package message
 import (
 	"fmt"
 	"hash/crc32"
+	"strings"
 
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/db"
@@ -25,9 +26,20 @@ func (m *messageUserExtraDB) insert(md *messageUserExtraModel) error {
 	_, err := m.session.InsertInto(m.getTable(md.UID)).Columns(util.AttrToUnderscore(md)...).Record(md).Exec()
 	return err
 }
-func (m *messageUserExtraDB) insertOrUpdateDeleted(md *messageUserExtraModel) error {
-	sq := fmt.Sprintf("INSERT INTO %s (uid,message_id,message_seq,channel_id,channel_type,message_is_deleted) VALUES (?,?,?,?,?,?) ON DUPLICATE KEY UPDATE  message_is_deleted=VALUES(message_is_deleted)", m.getTable(md.UID))
-	_, err := m.session.InsertBySql(sq, md.UID, md.MessageID, md.MessageSeq, md.ChannelID, md.ChannelType, md.MessageIsDeleted).Exec()
+
+// 批量标记消息已删除（同一个用户的数据在同一张分表内，一条语句写入）
+func (m *messageUserExtraDB) insertOrUpdateDeletedBatch(uid string, models []*messageUserExtraModel) error {
+	if len(models) == 0 {
+		return nil
+	}
+	placeholders := make([]string, 0, len(models))
+	values := make([]interface{}, 0, len(models)*6)
+	for _, md := range models {
+		placeholders = append(placeholders, "(?,?,?,?,?,?)")
+		values = append(values, uid, md.MessageID, md.MessageSeq, md.ChannelID, md.ChannelType, md.MessageIsDeleted)
+	}
+	sq := fmt.Sprintf("INSERT INTO %s (uid,message_id,message_seq,channel_id,channel_type,message_is_deleted) VALUES %s ON DUPLICATE KEY UPDATE message_is_deleted=VALUES(message_is_deleted)", m.getTable(uid), strings.Join(placeholders, ","))
+	_, err := m.session.InsertBySql(sq, values...).Exec()
 	return err
 }
 
@@ -41,8 +53,49 @@ func (m *messageUserExtraDB) queryWithMessageIDsAndUID(messageIDs []string, uid
 	return models, err
 }
 
+// 标记语音已读（已播放）
+func (m *messageUserExtraDB) insertOrUpdateVoiceReaded(md *messageUserExtraModel) error {
+	sq := fmt.Sprintf("INSERT INTO %s (uid,message_id,message_seq,channel_id,channel_type,voice_readed) VALUES (?,?,?,?,?,?) ON DUPLICATE KEY UPDATE  voice_readed=VALUES(voice_readed)", m.getTable(md.UID))
+	_, err := m.session.InsertBySql(sq, md.UID, md.MessageID, md.MessageSeq, md.ChannelID, md.ChannelType, md.VoiceReaded).Exec()
+	return err
+}
+
+// 标记消息已读，返回是否由未读变为已读（已经是已读的不会更新，影响行数为0）
+func (m *messageUserExtraDB) insertOrUpdateReadedWithTx(tx *dbr.Tx, md *messageUserExtraModel) (bool, error) {
+	sq := fmt.Sprintf("INSERT INTO %s (uid,message_id,message_seq,channel_id,channel_type,readed,readed_at) VALUES (?,?,?,?,?,1,?) ON DUPLICATE KEY UPDATE readed_at=IF(readed=1,readed_at,VALUES(readed_at)),readed=1", m.getTable(md.UID))
+	result, err := tx.InsertBySql(sq, md.UID, md.MessageID, md.MessageSeq, md.ChannelID, md.ChannelType, md.ReadedAt).Exec()
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// 查询已读某条消息的用户（需要遍历所有分表）
+func (m *messageUserExtraDB) queryReadedWithMessageID(messageID string) ([]*messageReadedModel, error) {
+	models := make([]*messageReadedModel, 0)
+	tableCount := m.ctx.GetConfig().TablePartitionConfig.MessageUserEditTableCount
+	for i := 0; i < tableCount; i++ {
+		table := m.getTableWithIndex(uint32(i))
+		var tableModels []*messageReadedModel
+		_, err := m.session.Select(fmt.Sprintf("%s.uid,%s.readed_at,IFNULL(user.name,'') name", table, table)).From(table).LeftJoin("user", fmt.Sprintf("%s.uid=user.uid", table)).Where(fmt.Sprintf("%s.message_id=? and %s.readed=1", table, table), messageID).Load(&tableModels)
+		if err != nil {
+			return nil, err
+		}
+		models = append(models, tableModels...)
+	}
+	return models, nil
+}
+
 func (m *messageUserExtraDB) getTable(uid string) string {
 	tableIndex := crc32.ChecksumIEEE([]byte(uid)) % uint32(m.ctx.GetConfig().TablePartitionConfig.MessageUserEditTableCount)
+	return m.getTableWithIndex(tableIndex)
+}
+
+func (m *messageUserExtraDB) getTableWithIndex(tableIndex uint32) string {
 	if tableIndex == 0 {
 		return "message_user_extra"
 	}
@@ -57,5 +110,13 @@ type messageUserExtraModel struct {
 	ChannelType      uint8
 	VoiceReaded      int
 	MessageIsDeleted int
+	Readed           int   // 是否已读
+	ReadedAt         int64 // 已读时间
 	db.BaseModel
 }
+
+type messageReadedModel struct {
+	UID      string
+	Name     string
+	ReadedAt int64
+}
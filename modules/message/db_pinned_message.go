@@ -0,0 +1,73 @@
+package message
+
+import (
+	"sort"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/db"
+	"github.com/gocraft/dbr/v2"
+)
+
+type pinnedMessageDB struct {
+	ctx     *config.Context
+	session *dbr.Session
+}
+
+func newPinnedMessageDB(ctx *config.Context) *pinnedMessageDB {
+	return &pinnedMessageDB{
+		ctx:     ctx,
+		session: ctx.DB(),
+	}
+}
+
+// 置顶或取消置顶（取消置顶只做删除标记，方便客户端增量同步）
+func (p *pinnedMessageDB) insertOrUpdate(m *pinnedMessageModel) error {
+	_, err := p.session.InsertBySql("INSERT INTO pinned_message (message_id,message_seq,channel_id,channel_type,operator,is_deleted,version) VALUES (?,?,?,?,?,?,?) ON DUPLICATE KEY UPDATE operator=VALUES(operator),is_deleted=VALUES(is_deleted),version=VALUES(version)", m.MessageID, m.MessageSeq, m.ChannelID, m.ChannelType, m.Operator, m.IsDeleted, m.Version).Exec()
+	return err
+}
+
+func (p *pinnedMessageDB) insertOrUpdateWithTx(tx *dbr.Tx, m *pinnedMessageModel) error {
+	_, err := tx.InsertBySql("INSERT INTO pinned_message (message_id,message_seq,channel_id,channel_type,operator,is_deleted,version) VALUES (?,?,?,?,?,?,?) ON DUPLICATE KEY UPDATE operator=VALUES(operator),is_deleted=VALUES(is_deleted),version=VALUES(version)", m.MessageID, m.MessageSeq, m.ChannelID, m.ChannelType, m.Operator, m.IsDeleted, m.Version).Exec()
+	return err
+}
+
+func (p *pinnedMessageDB) queryWithMessageID(messageID string) (*pinnedMessageModel, error) {
+	var model *pinnedMessageModel
+	_, err := p.session.Select("*").From("pinned_message").Where("message_id=?", messageID).Load(&model)
+	return model, err
+}
+
+func (p *pinnedMessageDB) sync(version int64, channelID string, channelType uint8, limit uint64) ([]*pinnedMessageModel, error) {
+	var models []*pinnedMessageModel
+	builder := p.session.Select("*").From("pinned_message")
+	var err error
+	if version == 0 {
+		// 首次同步只需要未取消的置顶
+		builder = builder.Where("channel_id=? and channel_type=? and is_deleted=0", channelID, channelType).OrderDesc("version").Limit(limit)
+		_, err = builder.Load(&models)
+		newModels := pinnedMessageModelSlice(models)
+		sort.Sort(newModels)
+		models = newModels
+	} else {
+		builder = builder.Where("channel_id=? and channel_type=? and version>?", channelID, channelType, version).OrderAsc("version").Limit(limit)
+		_, err = builder.Load(&models)
+	}
+	return models, err
+}
+
+type pinnedMessageModelSlice []*pinnedMessageModel
+
+func (p pinnedMessageModelSlice) Len() int           { return len(p) }
+func (p pinnedMessageModelSlice) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+func (p pinnedMessageModelSlice) Less(i, j int) bool { return p[i].Version < p[j].Version }
+
+type pinnedMessageModel struct {
+	MessageID   string
+	MessageSeq  uint32
+	ChannelID   string
+	ChannelType uint8
+	Operator    string // 置顶操作者
+	IsDeleted   int
+	Version     int64 // 数据版本
+	db.BaseModel
+}
@@ -0,0 +1,100 @@
+package message
+
+import (
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/db"
+	"github.com/gocraft/dbr/v2"
+)
+
+const (
+	// ReminderTypeMentionMe 有人@我
+	ReminderTypeMentionMe = 1
+)
+
+type remindersDB struct {
+	ctx     *config.Context
+	session *dbr.Session
+}
+
+func newRemindersDB(ctx *config.Context) *remindersDB {
+	return &remindersDB{
+		ctx:     ctx,
+		session: ctx.DB(),
+	}
+}
+
+// 批量添加提醒项
+func (r *remindersDB) inserts(models []*remindersModel) error {
+	if len(models) == 0 {
+		return nil
+	}
+	builder := r.session.InsertInto("reminders").Columns("channel_id", "channel_type", "message_id", "message_seq", "client_msg_no", "reminder_type", "uid", "publisher", "text", "data", "is_locate", "version")
+	for _, m := range models {
+		builder = builder.Values(m.ChannelID, m.ChannelType, m.MessageID, m.MessageSeq, m.ClientMsgNo, m.ReminderType, m.UID, m.Publisher, m.Text, m.Data, m.IsLocate, m.Version)
+	}
+	_, err := builder.Exec()
+	return err
+}
+
+// 同步用户的提醒项（包含发给用户自己的和发给用户所在群所有成员的）
+// 提醒项或用户的完成状态有变化都会同步
+func (r *remindersDB) sync(uid string, groupNos []string, version int64, limit uint64) ([]*reminderDetailModel, error) {
+	var models []*reminderDetailModel
+	_, err := r.session.Select("reminders.*,IF(reminder_done.id IS NULL,0,1) done,IFNULL(reminder_done.version,0) done_version").From("reminders").
+		LeftJoin("reminder_done", dbr.Expr("reminders.id=reminder_done.reminder_id and reminder_done.uid=?", uid)).
+		Where("(reminders.version>? or reminder_done.version>?) and reminders.publisher<>? and (reminders.uid=? or (reminders.uid='' and reminders.channel_id in ?))", version, version, uid, uid, groupNos).
+		OrderAsc("GREATEST(reminders.version,IFNULL(reminder_done.version,0))").Limit(limit).Load(&models)
+	return models, err
+}
+
+// 标记提醒项已完成（只处理用户能看到的提醒项）
+func (r *remindersDB) done(ids []int64, uid string, groupNos []string, version int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := r.session.InsertBySql("INSERT IGNORE INTO reminder_done (reminder_id,uid,version) SELECT id,?,? FROM reminders WHERE id in ? and is_deleted=0 and publisher<>? and (uid=? or (uid='' and channel_id in ?))", uid, version, ids, uid, uid, groupNos).Exec()
+	return err
+}
+
+// 删除消息对应的提醒项（只做删除标记，方便客户端增量同步）
+func (r *remindersDB) deleteWithMessageIDs(messageIDs []string, version int64) error {
+	if len(messageIDs) == 0 {
+		return nil
+	}
+	_, err := r.session.Update("reminders").SetMap(map[string]interface{}{
+		"is_deleted": 1,
+		"version":    version,
+	}).Where("message_id in ? and is_deleted=0", messageIDs).Exec()
+	return err
+}
+
+type remindersModel struct {
+	ChannelID    string
+	ChannelType  uint8
+	MessageID    string
+	MessageSeq   uint32
+	ClientMsgNo  string
+	ReminderType int
+	UID          string // 为空表示频道内所有成员
+	Publisher    string
+	Text         string
+	Data         string
+	IsLocate     int
+	IsDeleted    int
+	Version      int64
+	db.BaseModel
+}
+
+type reminderDetailModel struct {
+	remindersModel
+	Done        int   // 当前用户是否已完成
+	DoneVersion int64 // 当前用户完成时的版本
+}
+
+// 用户看到的版本（提醒项版本和完成版本取最大的）
+func (r *reminderDetailModel) userVersion() int64 {
+	if r.DoneVersion > r.Version {
+		return r.DoneVersion
+	}
+	return r.Version
+}
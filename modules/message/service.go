@@ -0,0 +1,30 @@
+package message
+
+import (
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+)
+
+// IService 消息服务（提供给其他模块使用，其他模块不直接查询消息的表）
+type IService interface {
+	// GetMuteUIDs 获取在指定频道设置了免打扰的用户
+	GetMuteUIDs(uids []string, channelID string, channelType uint8) ([]string, error)
+}
+
+// Service Service
+type Service struct {
+	ctx              *config.Context
+	channelSettingDB *channelSettingDB
+}
+
+// NewService NewService
+func NewService(ctx *config.Context) IService {
+	return &Service{
+		ctx:              ctx,
+		channelSettingDB: newChannelSettingDB(ctx),
+	}
+}
+
+// GetMuteUIDs 获取在指定频道设置了免打扰的用户
+func (s *Service) GetMuteUIDs(uids []string, channelID string, channelType uint8) ([]string, error) {
+	return s.channelSettingDB.queryMuteUIDs(uids, channelID, channelType)
+}
@@ -0,0 +1,19 @@
+-- +migrate Up
+
+-- 置顶消息（按频道）
+CREATE TABLE `pinned_message`(
+    id           bigint          not null primary key AUTO_INCREMENT,
+    message_id   VARCHAR(20) not null default '',  -- 消息唯一ID（全局唯一）
+    message_seq  bigint not null default 0,  -- 消息序列号
+    channel_id   VARCHAR(100)      not null default '', -- 频道ID
+    channel_type smallint         not null default 0,  -- 频道类型
+    operator     VARCHAR(40) not null default '',  -- 置顶操作者uid
+    is_deleted   smallint     not null default 0,  -- 是否已取消置顶
+    `version`    bigint          not null default 0, -- 数据版本
+    created_at timeStamp     not null DEFAULT CURRENT_TIMESTAMP, -- 创建时间
+    updated_at timeStamp     not null DEFAULT CURRENT_TIMESTAMP  -- 更新时间
+);
+CREATE UNIQUE INDEX pinned_message_messageID on `pinned_message` (message_id);
+CREATE INDEX pinned_message_channel_idx on `pinned_message` (channel_id,channel_type);
+
+ALTER TABLE `message_extra` ADD COLUMN is_pinned smallint not null default 0 COMMENT '是否置顶';
@@ -0,0 +1,17 @@
+-- +migrate Up
+
+-- 消息回应（表情）
+CREATE TABLE `message_reaction`(
+    id           bigint          not null primary key AUTO_INCREMENT,
+    uid          VARCHAR(40) not null default '',  -- 回应用户uid
+    message_id   VARCHAR(20) not null default '',  -- 消息唯一ID（全局唯一）
+    channel_id   VARCHAR(100)      not null default '', -- 频道ID
+    channel_type smallint         not null default 0,  -- 频道类型
+    emoji        VARCHAR(20) not null default '',  -- 回应的表情
+    seq          bigint          not null default 0, -- 回应序号（用于增量同步）
+    is_deleted   smallint     not null default 0,  -- 是否已取消回应
+    created_at timeStamp     not null DEFAULT CURRENT_TIMESTAMP, -- 创建时间
+    updated_at timeStamp     not null DEFAULT CURRENT_TIMESTAMP  -- 更新时间
+);
+CREATE UNIQUE INDEX message_reaction_message_uid_emoji on `message_reaction` (message_id,uid,emoji);
+CREATE INDEX message_reaction_channel_idx on `message_reaction` (channel_id,channel_type,seq);
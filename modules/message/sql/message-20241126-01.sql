@@ -0,0 +1,13 @@
+-- +migrate Up
+
+ALTER TABLE `message_user_extra` ADD COLUMN readed smallint not null default 0 COMMENT '消息是否已读';
+ALTER TABLE `message_user_extra` ADD COLUMN readed_at bigint not null default 0 COMMENT '已读时间 时间戳（秒）';
+CREATE INDEX message_readed_idx on `message_user_extra` (message_id,readed);
+
+ALTER TABLE `message_user_extra1` ADD COLUMN readed smallint not null default 0 COMMENT '消息是否已读';
+ALTER TABLE `message_user_extra1` ADD COLUMN readed_at bigint not null default 0 COMMENT '已读时间 时间戳（秒）';
+CREATE INDEX message_readed_idx on `message_user_extra1` (message_id,readed);
+
+ALTER TABLE `message_user_extra2` ADD COLUMN readed smallint not null default 0 COMMENT '消息是否已读';
+ALTER TABLE `message_user_extra2` ADD COLUMN readed_at bigint not null default 0 COMMENT '已读时间 时间戳（秒）';
+CREATE INDEX message_readed_idx on `message_user_extra2` (message_id,readed);
@@ -0,0 +1,34 @@
+-- +migrate Up
+
+-- 提醒项（例如：有人@我）
+CREATE TABLE `reminders`(
+    id            bigint          not null primary key AUTO_INCREMENT,
+    channel_id    VARCHAR(100)      not null default '', -- 频道ID
+    channel_type  smallint         not null default 0,  -- 频道类型
+    message_id    VARCHAR(20) not null default '',  -- 消息唯一ID（全局唯一）
+    message_seq   bigint not null default 0,  -- 消息序列号
+    client_msg_no VARCHAR(40) not null default '',  -- 客户端消息编号
+    reminder_type integer not null default 0, -- 提醒类型 1.有人@我
+    uid           VARCHAR(40) not null default '',  -- 提醒的用户uid，为空表示频道内所有成员
+    publisher     VARCHAR(40) not null default '',  -- 提醒发布者uid
+    text          VARCHAR(255) not null default '', -- 提醒文本
+    data          VARCHAR(1000) not null default '', -- 自定义数据
+    is_locate     smallint not null default 0,  -- 是否需要定位到消息
+    is_deleted    smallint not null default 0,  -- 是否已删除
+    `version`     bigint not null default 0, -- 数据版本
+    created_at timeStamp     not null DEFAULT CURRENT_TIMESTAMP, -- 创建时间
+    updated_at timeStamp     not null DEFAULT CURRENT_TIMESTAMP  -- 更新时间
+);
+CREATE INDEX reminders_channel_idx on `reminders` (channel_id,channel_type);
+CREATE INDEX reminders_uid_idx on `reminders` (uid);
+CREATE INDEX reminders_message_idx on `reminders` (message_id);
+
+-- 用户已完成的提醒
+CREATE TABLE `reminder_done`(
+    id            bigint          not null primary key AUTO_INCREMENT,
+    reminder_id   bigint not null default 0, -- 提醒项ID
+    uid           VARCHAR(40) not null default '',  -- 用户uid
+    created_at timeStamp     not null DEFAULT CURRENT_TIMESTAMP, -- 创建时间
+    updated_at timeStamp     not null DEFAULT CURRENT_TIMESTAMP  -- 更新时间
+);
+CREATE UNIQUE INDEX reminder_done_reminderID_uid on `reminder_done` (reminder_id,uid);
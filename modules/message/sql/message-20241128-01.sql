@@ -0,0 +1,18 @@
+-- +migrate Up
+
+-- 最近会话扩展（草稿、浏览位置等，按用户）
+CREATE TABLE `conversation_extra`(
+    id               bigint          not null primary key AUTO_INCREMENT,
+    uid              VARCHAR(40) not null default '',  -- 所属用户
+    channel_id       VARCHAR(100)      not null default '', -- 频道ID
+    channel_type     smallint         not null default 0,  -- 频道类型
+    browse_to        bigint not null default 0,  -- 预览到的位置，与会话保持位置不同的是 预览到的位置是用户读到的最大的messageSeq。跟未读消息数量有关系
+    keep_message_seq bigint not null default 0,  -- 会话保持的位置
+    keep_offset_y    integer not null default 0,  -- 会话保持的位置的偏移量
+    draft            VARCHAR(1000) not null default '', -- 草稿
+    `version`        bigint          not null default 0, -- 数据版本
+    created_at timeStamp     not null DEFAULT CURRENT_TIMESTAMP, -- 创建时间
+    updated_at timeStamp     not null DEFAULT CURRENT_TIMESTAMP  -- 更新时间
+);
+CREATE UNIQUE INDEX conversation_extra_uid_channel on `conversation_extra` (uid,channel_id,channel_type);
+CREATE INDEX conversation_extra_uid_version on `conversation_extra` (uid,version);
@@ -0,0 +1,18 @@
+-- +migrate Up
+
+-- 用户的频道设置（置顶、免打扰、隐藏、备注）
+CREATE TABLE `channel_setting`(
+    id           bigint          not null primary key AUTO_INCREMENT,
+    uid          VARCHAR(40) not null default '',  -- 所属用户
+    channel_id   VARCHAR(100)      not null default '', -- 频道ID
+    channel_type smallint         not null default 0,  -- 频道类型
+    top          smallint not null default 0,  -- 是否置顶
+    mute         smallint not null default 0,  -- 是否免打扰
+    hide         smallint not null default 0,  -- 是否从最近会话列表隐藏
+    remark       VARCHAR(100) not null default '', -- 备注
+    `version`    bigint          not null default 0, -- 数据版本
+    created_at timeStamp     not null DEFAULT CURRENT_TIMESTAMP, -- 创建时间
+    updated_at timeStamp     not null DEFAULT CURRENT_TIMESTAMP  -- 更新时间
+);
+CREATE UNIQUE INDEX channel_setting_uid_channel on `channel_setting` (uid,channel_id,channel_type);
+CREATE INDEX channel_setting_channel_idx on `channel_setting` (channel_id,channel_type);
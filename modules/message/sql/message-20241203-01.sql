@@ -0,0 +1,5 @@
+-- +migrate Up
+
+-- 用户完成提醒项的版本（完成状态按用户同步，不再修改共享的提醒项版本）
+ALTER TABLE `reminder_done` ADD COLUMN `version` bigint not null default 0;
+CREATE INDEX reminder_done_uid_version on `reminder_done` (uid,`version`);
@@ -0,0 +1,16 @@
+-- +migrate Up
+
+-- 频道内单调递增的序号（按用途区分，用于增量同步）
+-- 没有自增id，LAST_INSERT_ID(expr)返回的就是序号
+CREATE TABLE `channel_seq`(
+    channel_id   VARCHAR(100)      not null default '', -- 频道ID
+    channel_type smallint         not null default 0,  -- 频道类型
+    flag         VARCHAR(40) not null default '',  -- 序号用途
+    seq          bigint          not null default 0, -- 当前序号
+    created_at timeStamp     not null DEFAULT CURRENT_TIMESTAMP, -- 创建时间
+    updated_at timeStamp     not null DEFAULT CURRENT_TIMESTAMP, -- 更新时间
+    primary key (channel_id,channel_type,flag)
+);
+
+-- 从已有数据的最大值开始递增，客户端已同步到的序号继续有效
+INSERT INTO `channel_seq` (channel_id,channel_type,flag,seq) SELECT channel_id,channel_type,'messageReaction',MAX(seq) FROM `message_reaction` GROUP BY channel_id,channel_type;
@@ -0,0 +1,4 @@
+-- +migrate Up
+
+-- 置顶消息版本号改用频道序号，从已有数据的最大版本号开始递增
+INSERT INTO `channel_seq` (channel_id,channel_type,flag,seq) SELECT channel_id,channel_type,'pinnedMessage',MAX(`version`) FROM `pinned_message` GROUP BY channel_id,channel_type;
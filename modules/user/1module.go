@@ -16,12 +16,18 @@ func init() {
 	register.AddModule(func(ctx interface{}) register.Module {
 		x := ctx.(*config.Context)
 		api := New(x)
+		x.AddOnlineStatusListener(api.handleOnlineStatus)
 		return register.Module{
 			Name: "user",
 			SetupAPI: func() register.APIRouter {
 				return api
 			},
 			SQLDir: register.NewSQLFS(sqlFS),
+			IMDatasource: register.IMDatasource{
+				SystemUIDs: func() ([]string, error) {
+					return api.db.queryUIDsWithCategory(CategorySystem)
+				},
+			},
 		}
 	})
 }
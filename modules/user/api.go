@@ -9,17 +9,17 @@ import (
 
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/log"
-	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/network"
-	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/util"
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/wkhttp"
 	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/base"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/group"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 )
 
 // User 用户相关API
 type User struct {
-	db *DB
+	db           *DB
+	groupService group.IService
 	log.Log
 	ctx *config.Context
 }
@@ -27,9 +27,10 @@ type User struct {
 // New New
 func New(ctx *config.Context) *User {
 	u := &User{
-		ctx: ctx,
-		db:  NewDB(ctx),
-		Log: log.NewTLog("User"),
+		ctx:          ctx,
+		db:           NewDB(ctx),
+		groupService: group.NewService(ctx),
+		Log:          log.NewTLog("User"),
 	}
 	return u
 }
@@ -39,10 +40,14 @@ func (u *User) Route(r *wkhttp.WKHttp) {
 
 	v := r.Group("/v1")
 	{
-		v.POST("/user/login", u.login)        // 用户登录
-		v.GET("/users/:uid/route", u.route)   // 获取用户路由
-		v.GET("/users/:uid", u.get)           // 根据uid查询用户信息
-		v.GET("/users/:uid/avatar", u.avatar) // 获取用户头像
+		v.POST("/user/login", u.login)                          // 用户登录
+		v.GET("/users/:uid/route", u.route)                     // 获取用户路由
+		v.GET("/users/:uid", u.get)                             // 根据uid查询用户信息
+		v.GET("/users/:uid/avatar", u.avatar)                   // 获取用户头像
+		v.GET("/users/:uid/online", u.online)                   // 获取用户在线状态
+		v.POST("/users/online", u.onlines)                      // 批量获取用户在线状态
+		v.POST("/user/device_token", u.registerDeviceToken)     // 注册推送设备token
+		v.DELETE("/user/device_token", u.unregisterDeviceToken) // 注销推送设备token
 	}
 }
 
@@ -67,27 +72,16 @@ func (u *User) avatar(c *wkhttp.Context) {
 
 // 获取连接IM地址
 func (u *User) route(c *wkhttp.Context) {
-	resp, err := network.Get(base.APIURL+"/route", nil, nil)
-	if err != nil {
-		u.Error("更新IM token错误", zap.Error(err))
-		c.ResponseError(err)
-		return
-	}
-	err = base.HandlerIMError(resp)
+	result, err := base.IM().Route()
 	if err != nil {
+		u.Error("获取IM路由错误", zap.Error(err))
 		c.ResponseError(err)
 		return
 	}
-	var result *userRouteResp
-	if err := util.ReadJsonByByte([]byte(resp.Body), &result); err != nil {
-		u.Error("解析结果错误", zap.Error(err))
-		c.ResponseError(errors.New("解析结果错误"))
-		return
-	}
 	c.Response(&userRouteResp{
-		TcpAddr: result.TcpAddr,
-		WsAddr:  result.WsAddr,
-		WssAddr: result.WssAddr,
+		TcpAddr: result.TCPAddr,
+		WsAddr:  result.WSAddr,
+		WssAddr: result.WSSAddr,
 	})
 }
 
@@ -163,28 +157,17 @@ func (u *User) login(c *wkhttp.Context) {
 		name = model.Name
 	}
 	// 将用户信息注册到WuKongIM，如果存在则更新
-	resp, err := network.Post(base.APIURL+"/user/token", []byte(util.ToJson(map[string]interface{}{
-		"uid":          req.UID,
-		"token":        req.Token,
-		"device_level": req.DeviceLevel,
-		"device_flag":  req.DeviceFlag,
-	})), nil)
+	_, err = base.IM().UpdateToken(&config.UpdateIMTokenReq{
+		UID:         req.UID,
+		Token:       req.Token,
+		DeviceFlag:  config.DeviceFlag(req.DeviceFlag),
+		DeviceLevel: config.DeviceLevel(req.DeviceLevel),
+	})
 	if err != nil {
 		u.Error("更新IM token错误", zap.Error(err))
 		c.ResponseError(err)
 		return
 	}
-	err = base.HandlerIMError(resp)
-	if err != nil {
-		c.ResponseError(err)
-		return
-	}
-	var result *UpdateIMTokenResp
-	if err := util.ReadJsonByByte([]byte(resp.Body), &result); err != nil {
-		u.Error("解析结果错误", zap.Error(err))
-		c.ResponseError(errors.New("解析结果错误"))
-		return
-	}
 	c.Response(&userResp{
 		UID:   req.UID,
 		Name:  name,
@@ -198,10 +181,6 @@ type userRouteResp struct {
 	WssAddr string `json:"wss_addr"` // WebSocket Secure地址
 }
 
-// UpdateIMTokenResp 更新IM Token的返回参数
-type UpdateIMTokenResp struct {
-	Status int `json:"status"` // 状态
-}
 type loginReq struct {
 	UID         string `json:"uid"`
 	Token       string `json:"token"`
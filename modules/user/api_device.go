@@ -0,0 +1,82 @@
+package user
+
+import (
+	"strings"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/wkhttp"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/pkg/push"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// 注册推送设备token（app登录后调用，用于离线推送）
+func (u *User) registerDeviceToken(c *wkhttp.Context) {
+	var req deviceTokenReq
+	if err := c.BindJSON(&req); err != nil {
+		c.ResponseError(errors.New("请求数据格式有误！"))
+		return
+	}
+	if err := req.check(); err != nil {
+		c.ResponseError(err)
+		return
+	}
+	err := u.db.insertOrUpdateDevice(&deviceModel{
+		UID:         req.LoginUID,
+		DeviceType:  req.DeviceType,
+		DeviceToken: req.DeviceToken,
+		BundleID:    req.BundleID,
+	})
+	if err != nil {
+		u.Error("注册推送设备失败", zap.Error(err))
+		c.ResponseError(errors.New("注册推送设备失败"))
+		return
+	}
+	c.ResponseOK()
+}
+
+// 注销推送设备token（app退出登录时调用）
+func (u *User) unregisterDeviceToken(c *wkhttp.Context) {
+	var req struct {
+		LoginUID    string `json:"login_uid"`
+		DeviceToken string `json:"device_token"` // 厂商推送token
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.ResponseError(errors.New("请求数据格式有误！"))
+		return
+	}
+	if strings.TrimSpace(req.LoginUID) == "" {
+		c.ResponseError(errors.New("登录用户ID不能为空"))
+		return
+	}
+	if strings.TrimSpace(req.DeviceToken) == "" {
+		c.ResponseError(errors.New("设备token不能为空"))
+		return
+	}
+	err := u.db.deleteDevice(req.LoginUID, req.DeviceToken)
+	if err != nil {
+		u.Error("注销推送设备失败", zap.Error(err))
+		c.ResponseError(errors.New("注销推送设备失败"))
+		return
+	}
+	c.ResponseOK()
+}
+
+type deviceTokenReq struct {
+	LoginUID    string `json:"login_uid"`
+	DeviceType  string `json:"device_type"`  // 设备类型 IOS,HMS,MI,VIVO,OPPO,FIREBASE
+	DeviceToken string `json:"device_token"` // 厂商推送token
+	BundleID    string `json:"bundle_id"`    // 应用包名
+}
+
+func (d deviceTokenReq) check() error {
+	if strings.TrimSpace(d.LoginUID) == "" {
+		return errors.New("登录用户ID不能为空")
+	}
+	if strings.TrimSpace(d.DeviceToken) == "" {
+		return errors.New("设备token不能为空")
+	}
+	if !push.IsValidDeviceType(d.DeviceType) {
+		return errors.New("不支持的设备类型")
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package user
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/common"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/util"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/wkhttp"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/base"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// 批量查询在线状态的最大用户数
+const maxOnlineQueryCount = 1000
+
+// 获取用户在线状态
+func (u *User) online(c *wkhttp.Context) {
+	uid := c.Param("uid")
+	if strings.TrimSpace(uid) == "" {
+		c.ResponseError(errors.New("uid不能为空"))
+		return
+	}
+	resps, err := u.getOnlines([]string{uid})
+	if err != nil {
+		u.Error("查询用户在线状态失败", zap.Error(err))
+		c.ResponseError(errors.New("查询用户在线状态失败"))
+		return
+	}
+	c.Response(resps[0])
+}
+
+// 批量获取用户在线状态
+func (u *User) onlines(c *wkhttp.Context) {
+	var req struct {
+		UIDs []string `json:"uids"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.ResponseError(errors.New("请求数据格式有误！"))
+		return
+	}
+	if len(req.UIDs) == 0 {
+		c.ResponseError(errors.New("uids不能为空"))
+		return
+	}
+	if len(req.UIDs) > maxOnlineQueryCount {
+		c.ResponseError(fmt.Errorf("一次最多查询%d个用户", maxOnlineQueryCount))
+		return
+	}
+	resps, err := u.getOnlines(util.RemoveRepeatedElement(req.UIDs))
+	if err != nil {
+		u.Error("查询用户在线状态失败", zap.Error(err))
+		c.ResponseError(errors.New("查询用户在线状态失败"))
+		return
+	}
+	c.Response(resps)
+}
+
+// 查询用户在线状态，优先从缓存获取，缓存没有的从数据库获取
+func (u *User) getOnlines(uids []string) ([]*userOnlineResp, error) {
+	deviceMap := map[string][]*deviceOnlineResp{}
+	missUIDs := make([]string, 0)
+	for _, uid := range uids {
+		devices, err := u.getOnlineCache(uid)
+		if err != nil {
+			return nil, err
+		}
+		if len(devices) == 0 {
+			missUIDs = append(missUIDs, uid)
+			continue
+		}
+		deviceMap[uid] = devices
+	}
+	models, err := u.db.queryOnlinesWithUIDs(missUIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range models {
+		deviceMap[m.UID] = append(deviceMap[m.UID], &deviceOnlineResp{
+			DeviceFlag:  m.DeviceFlag,
+			Online:      m.Online,
+			LastOnline:  m.LastOnline,
+			LastOffline: m.LastOffline,
+		})
+	}
+	resps := make([]*userOnlineResp, 0, len(uids))
+	for _, uid := range uids {
+		resps = append(resps, newUserOnlineResp(uid, deviceMap[uid]))
+	}
+	return resps, nil
+}
+
+// 处理IM的在线状态变更
+func (u *User) handleOnlineStatus(onlineStatusList []config.OnlineStatus) {
+	for _, status := range onlineStatusList {
+		if err := u.updateOnlineStatus(status); err != nil {
+			u.Error("更新用户在线状态失败", zap.Error(err), zap.String("uid", status.UID), zap.Uint8("deviceFlag", status.DeviceFlag))
+			continue
+		}
+		u.sendOnlineStatusCMD(status)
+	}
+}
+
+func (u *User) updateOnlineStatus(status config.OnlineStatus) error {
+	now := time.Now().Unix()
+	m := &onlineModel{
+		UID:        status.UID,
+		DeviceFlag: status.DeviceFlag,
+	}
+	// 同一设备类型可能有多个连接，OnlineCount为此设备类型剩余的在线连接数
+	online := status.OnlineCount > 0
+	if online {
+		m.Online = 1
+		m.LastOnline = now
+	} else {
+		m.LastOffline = now
+	}
+	if err := u.db.insertOrUpdateOnline(m); err != nil {
+		return err
+	}
+
+	// 缓存保留上一次的上线/离线时间
+	device, err := u.getDeviceOnlineCache(status.UID, status.DeviceFlag)
+	if err != nil {
+		return err
+	}
+	if device == nil {
+		device = &deviceOnlineResp{DeviceFlag: status.DeviceFlag}
+	}
+	device.Online = m.Online
+	if online {
+		device.LastOnline = now
+	} else {
+		device.LastOffline = now
+	}
+	return u.ctx.GetRedisConn().Hset(onlineCacheKey(status.UID), fmt.Sprintf("%d", status.DeviceFlag), util.ToJson(device))
+}
+
+// 通知同群成员在线状态变化（暂无好友关系，同群成员即为联系人），通知人数受配置限制
+func (u *User) sendOnlineStatusCMD(status config.OnlineStatus) {
+	limit := base.GetConfig().User.OnlineNotifyLimit
+	if limit <= 0 {
+		return
+	}
+	peerUIDs, err := u.groupService.GetPeerUIDs(status.UID, uint64(limit))
+	if err != nil {
+		u.Error("查询同群成员失败", zap.Error(err), zap.String("uid", status.UID))
+		return
+	}
+	if len(peerUIDs) == 0 {
+		return
+	}
+	online := 0
+	if status.OnlineCount > 0 {
+		online = 1
+	}
+	allOffline := 0
+	if status.TotalOnlineCount == 0 {
+		allOffline = 1
+	}
+	err = base.SendCMD(config.MsgCMDReq{
+		NoPersist:   true,
+		ChannelID:   status.UID,
+		ChannelType: common.ChannelTypePerson.Uint8(),
+		Subscribers: peerUIDs,
+		CMD:         common.CMDOnlineStatus,
+		Param: map[string]interface{}{
+			"uid":         status.UID,
+			"device_flag": status.DeviceFlag,
+			"online":      online,
+			"all_offline": allOffline,
+		},
+	})
+	if err != nil {
+		u.Error("发送在线状态cmd失败", zap.Error(err), zap.String("uid", status.UID))
+	}
+}
+
+func onlineCacheKey(uid string) string {
+	return fmt.Sprintf("userOnline:%s", uid)
+}
+
+func (u *User) getOnlineCache(uid string) ([]*deviceOnlineResp, error) {
+	valueMap, err := u.ctx.GetRedisConn().Hgetall(onlineCacheKey(uid))
+	if err != nil {
+		return nil, err
+	}
+	devices := make([]*deviceOnlineResp, 0, len(valueMap))
+	for _, value := range valueMap {
+		var device *deviceOnlineResp
+		if err := util.ReadJsonByByte([]byte(value), &device); err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+	return devices, nil
+}
+
+func (u *User) getDeviceOnlineCache(uid string, deviceFlag uint8) (*deviceOnlineResp, error) {
+	value, err := u.ctx.GetRedisConn().Hget(onlineCacheKey(uid), fmt.Sprintf("%d", deviceFlag))
+	if err != nil {
+		return nil, err
+	}
+	if value == "" {
+		return nil, nil
+	}
+	var device *deviceOnlineResp
+	err = util.ReadJsonByByte([]byte(value), &device)
+	return device, err
+}
+
+type userOnlineResp struct {
+	UID         string              `json:"uid"`
+	Online      int                 `json:"online"`       // 是否在线（任一设备在线即为在线）
+	LastOffline int64               `json:"last_offline"` // 最后一次离线时间
+	Devices     []*deviceOnlineResp `json:"devices"`      // 各设备的在线状态
+}
+
+func newUserOnlineResp(uid string, devices []*deviceOnlineResp) *userOnlineResp {
+	resp := &userOnlineResp{
+		UID:     uid,
+		Devices: make([]*deviceOnlineResp, 0, len(devices)),
+	}
+	for _, device := range devices {
+		if device.Online == 1 {
+			resp.Online = 1
+		}
+		if device.LastOffline > resp.LastOffline {
+			resp.LastOffline = device.LastOffline
+		}
+		resp.Devices = append(resp.Devices, device)
+	}
+	return resp
+}
+
+type deviceOnlineResp struct {
+	DeviceFlag  uint8 `json:"device_flag"`  // 设备标记 0.app 1.web 2.pc
+	Online      int   `json:"online"`       // 是否在线
+	LastOnline  int64 `json:"last_online"`  // 最后一次上线时间
+	LastOffline int64 `json:"last_offline"` // 最后一次离线时间
+}
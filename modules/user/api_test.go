@@ -8,6 +8,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/util"
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/testutil"
 	"github.com/stretchr/testify/assert"
@@ -51,3 +52,94 @@ func TestGetUser(t *testing.T) {
 	fmt.Println(w.Body.String())
 	assert.Equal(t, true, strings.Contains(w.Body.String(), `"uid":`))
 }
+
+func TestRegisterDeviceToken(t *testing.T) {
+	s, ctx := testutil.NewTestServer()
+	u := New(ctx)
+	//清除数据
+	err := testutil.CleanAllTables(ctx)
+	assert.NoError(t, err)
+	register := func(uid string, deviceToken string) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/user/device_token", bytes.NewReader([]byte(util.ToJson(map[string]interface{}{
+			"login_uid":    uid,
+			"device_type":  "MI",
+			"device_token": deviceToken,
+			"bundle_id":    "com.test",
+		}))))
+		s.GetRoute().ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	// 同一个用户可以有多个设备
+	register("1", "token1")
+	register("1", "token2")
+	devices, err := u.db.queryDevicesWithUIDs([]string{"1"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(devices))
+
+	// 设备换了用户登录，原用户不再推送到此设备
+	register("2", "token1")
+	devices, err = u.db.queryDevicesWithUIDs([]string{"1"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(devices))
+	assert.Equal(t, "token2", devices[0].DeviceToken)
+
+	// 注销只删除当前设备
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/v1/user/device_token", bytes.NewReader([]byte(util.ToJson(map[string]interface{}{
+		"login_uid":    "2",
+		"device_token": "token1",
+	}))))
+	s.GetRoute().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	devices, err = u.db.queryDevicesWithUIDs([]string{"1", "2"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(devices))
+	assert.Equal(t, "1", devices[0].UID)
+}
+
+func TestUserOnline(t *testing.T) {
+	s, ctx := testutil.NewTestServer()
+	u := New(ctx)
+	//清除数据
+	err := testutil.CleanAllTables(ctx)
+	assert.NoError(t, err)
+	err = ctx.GetRedisConn().Del(onlineCacheKey("1"))
+	assert.NoError(t, err)
+
+	err = u.updateOnlineStatus(config.OnlineStatus{
+		UID:              "1",
+		DeviceFlag:       1,
+		Online:           true,
+		OnlineCount:      1,
+		TotalOnlineCount: 1,
+	})
+	assert.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/users/1/online", nil)
+	s.GetRoute().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, true, strings.Contains(w.Body.String(), `"online":1`))
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/v1/users/online", bytes.NewReader([]byte(util.ToJson(map[string]interface{}{
+		"uids": []string{"1", "2"},
+	}))))
+	s.GetRoute().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, true, strings.Contains(w.Body.String(), `"uid":"2","online":0`))
+
+	// 同一设备类型还有其他连接在线，设备仍然在线
+	err = u.updateOnlineStatus(config.OnlineStatus{
+		UID:              "1",
+		DeviceFlag:       1,
+		Online:           false,
+		OnlineCount:      1,
+		TotalOnlineCount: 1,
+	})
+	assert.NoError(t, err)
+	resps, err := u.getOnlines([]string{"1"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, resps[0].Online)
+}
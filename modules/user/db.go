@@ -31,9 +31,88 @@ func (d *DB) queryByUID(uid string) (*userModel, error) {
 	return model, err
 }
 
+// queryUIDsWithCategory 查询某个分类下的所有用户uid
+func (d *DB) queryUIDsWithCategory(category string) ([]string, error) {
+	var uids []string
+	_, err := d.session.Select("uid").From("user").Where("category=?", category).Load(&uids)
+	return uids, err
+}
+
+// 新增或更新用户的推送设备（同一个设备token只属于最后登录的用户）
+func (d *DB) insertOrUpdateDevice(m *deviceModel) error {
+	tx, err := d.session.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.RollbackUnlessCommitted()
+	_, err = tx.DeleteFrom("user_device").Where("device_token=? and uid<>?", m.DeviceToken, m.UID).Exec()
+	if err != nil {
+		return err
+	}
+	_, err = tx.InsertBySql("INSERT INTO user_device (uid,device_type,device_token,bundle_id) VALUES (?,?,?,?) ON DUPLICATE KEY UPDATE device_type=VALUES(device_type),bundle_id=VALUES(bundle_id)", m.UID, m.DeviceType, m.DeviceToken, m.BundleID).Exec()
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// 删除用户的推送设备
+func (d *DB) deleteDevice(uid string, deviceToken string) error {
+	_, err := d.session.DeleteFrom("user_device").Where("uid=? and device_token=?", uid, deviceToken).Exec()
+	return err
+}
+
+// 查询用户的推送设备
+func (d *DB) queryDevicesWithUIDs(uids []string) ([]*deviceModel, error) {
+	if len(uids) == 0 {
+		return nil, nil
+	}
+	var models []*deviceModel
+	_, err := d.session.Select("uid,device_type,device_token,bundle_id").From("user_device").Where("uid in ?", uids).Load(&models)
+	return models, err
+}
+
+// 更新用户设备的在线状态
+func (d *DB) insertOrUpdateOnline(m *onlineModel) error {
+	if m.Online == 1 {
+		_, err := d.session.InsertBySql("INSERT INTO user_online (uid,device_flag,online,last_online) VALUES (?,?,1,?) ON DUPLICATE KEY UPDATE online=1,last_online=VALUES(last_online)", m.UID, m.DeviceFlag, m.LastOnline).Exec()
+		return err
+	}
+	_, err := d.session.InsertBySql("INSERT INTO user_online (uid,device_flag,online,last_offline) VALUES (?,?,0,?) ON DUPLICATE KEY UPDATE online=0,last_offline=VALUES(last_offline)", m.UID, m.DeviceFlag, m.LastOffline).Exec()
+	return err
+}
+
+func (d *DB) queryOnlinesWithUIDs(uids []string) ([]*onlineModel, error) {
+	if len(uids) == 0 {
+		return nil, nil
+	}
+	var models []*onlineModel
+	_, err := d.session.Select("uid,device_flag,online,last_online,last_offline").From("user_online").Where("uid in ?", uids).Load(&models)
+	return models, err
+}
+
 // ------------ model ------------
 
 type userModel struct {
-	UID  string
-	Name string
+	UID      string
+	Name     string
+	Category string // 用户分类
+}
+
+// CategorySystem 系统账号
+const CategorySystem = "system"
+
+type deviceModel struct {
+	UID         string
+	DeviceType  string // 设备类型（推送厂商）
+	DeviceToken string // 厂商推送token
+	BundleID    string // 应用包名
+}
+
+type onlineModel struct {
+	UID         string
+	DeviceFlag  uint8 // 设备标记
+	Online      int   // 是否在线
+	LastOnline  int64 // 最后一次上线时间
+	LastOffline int64 // 最后一次离线时间
 }
@@ -0,0 +1,72 @@
+package user
+
+import (
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+)
+
+// IService 用户服务（提供给其他模块使用，其他模块不直接查询用户的表）
+type IService interface {
+	// GetUserName 获取用户名，用户不存在返回空
+	GetUserName(uid string) (string, error)
+	// GetDevicesWithUIDs 获取用户的推送设备
+	GetDevicesWithUIDs(uids []string) ([]*DeviceResp, error)
+	// DeleteDevice 删除用户的推送设备（例如推送token已失效）
+	DeleteDevice(uid string, deviceToken string) error
+}
+
+// Service Service
+type Service struct {
+	ctx *config.Context
+	db  *DB
+}
+
+// NewService NewService
+func NewService(ctx *config.Context) IService {
+	return &Service{
+		ctx: ctx,
+		db:  NewDB(ctx),
+	}
+}
+
+// GetUserName 获取用户名，用户不存在返回空
+func (s *Service) GetUserName(uid string) (string, error) {
+	model, err := s.db.queryByUID(uid)
+	if err != nil {
+		return "", err
+	}
+	if model == nil {
+		return "", nil
+	}
+	return model.Name, nil
+}
+
+// GetDevicesWithUIDs 获取用户的推送设备
+func (s *Service) GetDevicesWithUIDs(uids []string) ([]*DeviceResp, error) {
+	models, err := s.db.queryDevicesWithUIDs(uids)
+	if err != nil {
+		return nil, err
+	}
+	resps := make([]*DeviceResp, 0, len(models))
+	for _, m := range models {
+		resps = append(resps, &DeviceResp{
+			UID:         m.UID,
+			DeviceType:  m.DeviceType,
+			DeviceToken: m.DeviceToken,
+			BundleID:    m.BundleID,
+		})
+	}
+	return resps, nil
+}
+
+// DeleteDevice 删除用户的推送设备
+func (s *Service) DeleteDevice(uid string, deviceToken string) error {
+	return s.db.deleteDevice(uid, deviceToken)
+}
+
+// DeviceResp 用户的推送设备
+type DeviceResp struct {
+	UID         string
+	DeviceType  string // 设备类型（推送厂商）
+	DeviceToken string // 厂商推送token
+	BundleID    string // 应用包名
+}
@@ -0,0 +1,4 @@
+-- +migrate Up
+
+ALTER TABLE `user` ADD COLUMN category VARCHAR(40) not null default '' COMMENT '用户分类 system:系统账号';
+CREATE INDEX user_category on `user` (category);
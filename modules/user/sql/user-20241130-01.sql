@@ -0,0 +1,13 @@
+-- +migrate Up
+
+-- 用户的推送设备（每个用户一个app设备）
+CREATE TABLE `user_device`(
+    id           bigint          not null primary key AUTO_INCREMENT,
+    uid          VARCHAR(40) not null default '',  -- 用户uid
+    device_type  VARCHAR(20) not null default '',  -- 设备类型（推送厂商） IOS,HMS,MI,VIVO,OPPO,FIREBASE
+    device_token VARCHAR(255) not null default '', -- 厂商推送token
+    bundle_id    VARCHAR(100) not null default '', -- 应用包名
+    created_at timeStamp     not null DEFAULT CURRENT_TIMESTAMP, -- 创建时间
+    updated_at timeStamp     not null DEFAULT CURRENT_TIMESTAMP  -- 更新时间
+);
+CREATE UNIQUE INDEX user_device_uid on `user_device` (uid);
@@ -0,0 +1,14 @@
+-- +migrate Up
+
+-- 用户在线状态（每个设备标记一条）
+CREATE TABLE `user_online`(
+    id           bigint          not null primary key AUTO_INCREMENT,
+    uid          VARCHAR(40) not null default '', -- 用户uid
+    device_flag  smallint    not null default 0,  -- 设备标记 0.app 1.web 2.pc
+    online       smallint    not null default 0,  -- 是否在线
+    last_online  integer     not null default 0,  -- 最后一次上线时间（10位时间戳）
+    last_offline integer     not null default 0,  -- 最后一次离线时间（10位时间戳）
+    created_at timeStamp     not null DEFAULT CURRENT_TIMESTAMP, -- 创建时间
+    updated_at timeStamp     not null DEFAULT CURRENT_TIMESTAMP  -- 更新时间
+);
+CREATE UNIQUE INDEX user_online_uid_device on `user_online` (uid, device_flag);
@@ -0,0 +1,6 @@
+-- +migrate Up
+
+-- 用户可以有多个推送设备（多台手机登录），按uid和device_token唯一
+DROP INDEX user_device_uid on `user_device`;
+CREATE UNIQUE INDEX user_device_uid_token on `user_device` (uid,device_token);
+CREATE INDEX user_device_token on `user_device` (device_token);
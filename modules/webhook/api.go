@@ -1,12 +1,23 @@
 package webhook
 
 import (
+	"context"
+	"errors"
 	"net"
+	"strconv"
+	"strings"
 
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/log"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/util"
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/wkhook"
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/wkhttp"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/base"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/group"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/message"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/user"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/pkg/pool"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/pkg/push"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 )
@@ -17,31 +28,59 @@ type Webhook struct {
 	ctx *config.Context
 	wkhook.UnimplementedWebhookServiceServer
 	grpcServer *grpc.Server
+
+	userService    user.IService
+	groupService   group.IService
+	messageService message.IService
+	pushProviders  map[string]push.Provider // 推送厂商，key为设备类型
+	pushCollector  pool.Collector           // 推送任务池
+
+	messageCollector pool.Collector // 消息处理任务池
 }
 
 // New New
 func New(ctx *config.Context) *Webhook {
 
-	return &Webhook{
-		ctx: ctx,
-		Log: log.NewTLog("Webhook"),
+	w := &Webhook{
+		ctx:            ctx,
+		Log:            log.NewTLog("Webhook"),
+		userService:    user.NewService(ctx),
+		groupService:   group.NewService(ctx),
+		messageService: message.NewService(ctx),
 	}
+	w.pushProviders = w.newPushProviders()
+	return w
 }
 
 // Route 路由配置
 func (w *Webhook) Route(r *wkhttp.WKHttp) {
-	r.POST("/v1/webhook", w.webhook)
+	r.POST("/v1/webhook", w.authMiddleware, w.webhook)
 
-	r.POST("/v2/webhook", w.webhook)
+	r.POST("/v2/webhook", w.authMiddleware, w.webhook)
 
-	r.POST("/v1/datasource", w.datasource)
+	r.POST("/v1/datasource", w.authMiddleware, w.datasource)
 
-	r.POST("/v1/webhook/message/notify", w.messageNotify) // 接受IM的消息通知,(TODO: 此接口需要与IM做安全认证)
+	r.POST("/v1/webhook/message/notify", w.authMiddleware, w.messageNotify) // 接受IM的消息通知
 
 }
 
 func (w *Webhook) Start() error {
-	w.grpcServer = grpc.NewServer()
+	pushPoolSize := w.ctx.GetConfig().Push.PushPoolSize
+	if pushPoolSize <= 0 {
+		pushPoolSize = defaultPushPoolSize
+	}
+	w.pushCollector = pool.StartDispatcher(pushPoolSize)
+
+	eventPoolSize := w.ctx.GetConfig().EventPoolSize
+	if eventPoolSize <= 0 {
+		eventPoolSize = defaultEventPoolSize
+	}
+	w.messageCollector = pool.StartDispatcher(eventPoolSize)
+
+	if base.GetConfig().Webhook.Secret == "" {
+		w.Warn("未配置webhook.secret，webhook和数据源请求不校验签名！")
+	}
+	w.grpcServer = grpc.NewServer(grpc.UnaryInterceptor(w.authUnaryInterceptor))
 
 	lis, err := net.Listen("tcp", w.ctx.GetConfig().GRPCAddr)
 	if err != nil {
@@ -63,11 +102,24 @@ func (w *Webhook) Start() error {
 
 func (w *Webhook) Stop() error {
 	w.grpcServer.Stop()
+	w.pushCollector.End <- true
+	w.messageCollector.End <- true
 	return nil
 }
 
+// 接收IM的消息通知（与msg.notify事件相同）
 func (w *Webhook) messageNotify(c *wkhttp.Context) {
-	// todo  接收IM的消息通知
+	data, err := c.GetRawData()
+	if err != nil {
+		w.Error("读取数据失败！", zap.Error(err))
+		c.ResponseError(err)
+		return
+	}
+	if err := w.handleMsgNotify(data); err != nil {
+		w.Error("处理消息通知失败！", zap.Error(err), zap.String("data", string(data)))
+		c.ResponseError(err)
+		return
+	}
 	c.ResponseOK()
 }
 
@@ -95,16 +147,98 @@ func (w *Webhook) webhook(c *wkhttp.Context) {
 
 }
 
+// SendWebhook 悟空IM通过grpc发送的webhook事件，与http的webhook处理逻辑相同
+func (w *Webhook) SendWebhook(ctx context.Context, req *wkhook.EventReq) (*wkhook.EventResp, error) {
+	result, err := w.handleEvent(req.Event, req.Data)
+	if err != nil {
+		w.Error("grpc事件处理失败！", zap.Error(err), zap.String("event", req.Event), zap.String("data", string(req.Data)))
+		return &wkhook.EventResp{
+			Status: wkhook.EventStatus_Error,
+			Data:   []byte(err.Error()),
+		}, nil
+	}
+	resp := &wkhook.EventResp{
+		Status: wkhook.EventStatus_Success,
+	}
+	if result != nil {
+		resp.Data = []byte(util.ToJson(result))
+	}
+	return resp, nil
+}
+
 func (w *Webhook) handleEvent(event string, data []byte) (interface{}, error) {
 	if event == "msg.offline" {
-		// todo 收到离线消息
-		return nil, nil
+		return nil, w.handleMsgOffline(data)
 	} else if event == "user.onlinestatus" {
-		// todo 在线
-		return nil, nil
+		return nil, w.handleOnlineStatus(data)
 	} else if event == "msg.notify" {
-		// todo 处理IM消息通知（所有消息）
-		return nil, nil
+		return nil, w.handleMsgNotify(data)
 	}
 	return nil, nil
 }
+
+// 处理IM消息通知（所有消息），分发给各模块的消息监听者和消息处理者
+func (w *Webhook) handleMsgNotify(data []byte) error {
+	var messages []*config.MessageResp
+	if err := util.ReadJsonByByte(data, &messages); err != nil {
+		return err
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+	w.ctx.NotifyMessagesListeners(messages)
+	w.dispatchMessages(messages)
+	return nil
+}
+
+// 处理IM的用户在线状态通知，分发给各模块的在线状态监听者
+func (w *Webhook) handleOnlineStatus(data []byte) error {
+	if !w.ctx.GetConfig().OnlineStatusOn {
+		return nil
+	}
+	var onlineStatusStrs []string
+	if err := util.ReadJsonByByte(data, &onlineStatusStrs); err != nil {
+		return err
+	}
+	onlineStatusList := make([]config.OnlineStatus, 0, len(onlineStatusStrs))
+	for _, onlineStatusStr := range onlineStatusStrs {
+		onlineStatus, err := parseOnlineStatus(onlineStatusStr)
+		if err != nil {
+			w.Warn("在线状态格式有误！", zap.Error(err), zap.String("onlineStatus", onlineStatusStr))
+			continue
+		}
+		onlineStatusList = append(onlineStatusList, onlineStatus)
+	}
+	if len(onlineStatusList) == 0 {
+		return nil
+	}
+	for _, listener := range w.ctx.GetAllOnlineStatusListeners() {
+		listener(onlineStatusList)
+	}
+	return nil
+}
+
+// 解析在线状态，格式为：uid-deviceFlag-status-socketID-onlineCount-totalOnlineCount（uid内可能包含“-”，所以从后往前解析）
+func parseOnlineStatus(onlineStatusStr string) (config.OnlineStatus, error) {
+	parts := strings.Split(onlineStatusStr, "-")
+	if len(parts) < 6 {
+		return config.OnlineStatus{}, errors.New("在线状态字段数量不足")
+	}
+	n := len(parts)
+	nums := make([]int64, 0, 5)
+	for _, part := range parts[n-5:] {
+		num, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return config.OnlineStatus{}, err
+		}
+		nums = append(nums, num)
+	}
+	return config.OnlineStatus{
+		UID:              strings.Join(parts[:n-5], "-"),
+		DeviceFlag:       uint8(nums[0]),
+		Online:           nums[1] == 1,
+		SocketID:         nums[2],
+		OnlineCount:      int(nums[3]),
+		TotalOnlineCount: int(nums[4]),
+	}, nil
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"strings"
 
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/register"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/util"
 	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/wkhttp"
 	"go.uber.org/zap"
 )
@@ -28,29 +30,117 @@ func (w *Webhook) datasource(c *wkhttp.Context) {
 	var err error
 	switch cmdReq.CMD {
 	case "getChannelInfo":
-		// todo 获取判断资料
-		result, err = nil, nil
+		result, err = w.getChannelInfo(cmdReq.Data)
 	case "getSubscribers":
-		// todo 获取订阅者
-		result, err = nil, nil
+		result, err = w.getChannelUIDs(cmdReq.Data, register.IMDatasourceTypeSubscribers, func(ds register.IMDatasource) channelUIDsFunc {
+			return ds.Subscribers
+		})
 	case "getBlacklist":
-		// todo 获取黑名单
-		result, err = nil, nil
+		result, err = w.getChannelUIDs(cmdReq.Data, register.IMDatasourceTypeBlacklist, func(ds register.IMDatasource) channelUIDsFunc {
+			return ds.Blacklist
+		})
 	case "getWhitelist":
-		// todo 获取白名单
-		result, err = nil, nil
+		result, err = w.getChannelUIDs(cmdReq.Data, register.IMDatasourceTypeWhitelist, func(ds register.IMDatasource) channelUIDsFunc {
+			return ds.Whitelist
+		})
 	case "getSystemUIDs":
-		// todo 获取系统账号
-		result, err = nil, nil
+		result, err = w.getSystemUIDs()
 	}
 
 	if err != nil {
+		w.Error("请求数据源失败！", zap.Error(err), zap.String("cmd", cmdReq.CMD))
 		c.ResponseError(err)
 		return
 	}
 	c.Response(result)
 }
 
+type channelUIDsFunc func(channelID string, channelType uint8) ([]string, error)
+
+// 获取频道资料
+func (w *Webhook) getChannelInfo(data map[string]interface{}) (interface{}, error) {
+	channelReq, err := newChannelReq(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range register.GetModules(w.ctx) {
+		ds := m.IMDatasource
+		if ds.HasData == nil || ds.ChannelInfo == nil {
+			continue
+		}
+		if !ds.HasData(channelReq.ChannelID, channelReq.ChannelType).Has(register.IMDatasourceTypeChannelInfo) {
+			continue
+		}
+		result, err := ds.ChannelInfo(channelReq.ChannelID, channelReq.ChannelType)
+		if errors.Is(err, register.ErrDatasourceNotProcess) {
+			continue
+		}
+		return result, err
+	}
+	return nil, nil
+}
+
+// 获取频道的uid列表（订阅者，黑名单，白名单）
+func (w *Webhook) getChannelUIDs(data map[string]interface{}, dataType register.IMDatasourceType, getFunc func(ds register.IMDatasource) channelUIDsFunc) ([]string, error) {
+	channelReq, err := newChannelReq(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range register.GetModules(w.ctx) {
+		ds := m.IMDatasource
+		fnc := getFunc(ds)
+		if ds.HasData == nil || fnc == nil {
+			continue
+		}
+		if !ds.HasData(channelReq.ChannelID, channelReq.ChannelType).Has(dataType) {
+			continue
+		}
+		uids, err := fnc(channelReq.ChannelID, channelReq.ChannelType)
+		if errors.Is(err, register.ErrDatasourceNotProcess) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if uids == nil {
+			uids = make([]string, 0)
+		}
+		return uids, nil
+	}
+	// 没有模块处理时返回空，由悟空IM使用自己的数据
+	return nil, nil
+}
+
+// 获取系统账号
+func (w *Webhook) getSystemUIDs() ([]string, error) {
+	uids := make([]string, 0)
+	for _, m := range register.GetModules(w.ctx) {
+		if m.IMDatasource.SystemUIDs == nil {
+			continue
+		}
+		systemUIDs, err := m.IMDatasource.SystemUIDs()
+		if errors.Is(err, register.ErrDatasourceNotProcess) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		uids = append(uids, systemUIDs...)
+	}
+	return uids, nil
+}
+
+func newChannelReq(data map[string]interface{}) (*ChannelReq, error) {
+	var channelReq *ChannelReq
+	if err := util.ReadJsonByByte([]byte(util.ToJson(data)), &channelReq); err != nil {
+		return nil, err
+	}
+	if channelReq == nil || strings.TrimSpace(channelReq.ChannelID) == "" {
+		return nil, errors.New("频道ID不能为空！")
+	}
+	return channelReq, nil
+}
+
 type ChannelReq struct {
 	ChannelID   string `json:"channel_id"`
 	ChannelType uint8  `json:"channel_type"`
@@ -0,0 +1,43 @@
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/log"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/wkhook"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOnlineStatus(t *testing.T) {
+	onlineStatus, err := parseOnlineStatus("u-1-1-1-100-1-2")
+	assert.NoError(t, err)
+	assert.Equal(t, "u-1", onlineStatus.UID)
+	assert.Equal(t, uint8(1), onlineStatus.DeviceFlag)
+	assert.Equal(t, true, onlineStatus.Online)
+	assert.Equal(t, int64(100), onlineStatus.SocketID)
+	assert.Equal(t, 1, onlineStatus.OnlineCount)
+	assert.Equal(t, 2, onlineStatus.TotalOnlineCount)
+
+	_, err = parseOnlineStatus("u1-1-1")
+	assert.Error(t, err)
+}
+
+func TestSendWebhook(t *testing.T) {
+	w := &Webhook{
+		Log: log.NewTLog("Webhook"),
+	}
+	resp, err := w.SendWebhook(context.Background(), &wkhook.EventReq{
+		Event: "unknown",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, wkhook.EventStatus_Success, resp.Status)
+
+	resp, err = w.SendWebhook(context.Background(), &wkhook.EventReq{
+		Event: "msg.notify",
+		Data:  []byte("invalid"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, wkhook.EventStatus_Error, resp.Status)
+}
@@ -0,0 +1,151 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/wkhook"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/wkhttp"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/base"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/pkg/util"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// 签名相关的请求头（grpc的metadata使用小写）
+const (
+	headerTimestamp = "X-WK-Timestamp" // 签名时间（10位时间戳）
+	headerNonce     = "X-WK-Nonce"     // 随机串，有效期内不能重复
+	headerSignature = "X-WK-Signature" // 签名
+)
+
+// 签名校验不通过的错误（返回401），其他错误为服务端错误
+var (
+	errInvalidSignature = errors.New("签名校验失败！")
+	errSignExpired      = errors.New("签名已过期！")
+	errNonceReused      = errors.New("重复的请求！")
+)
+
+func isSignatureError(err error) bool {
+	return errors.Is(err, errInvalidSignature) || errors.Is(err, errSignExpired) || errors.Is(err, errNonceReused)
+}
+
+// signContent 签名内容 格式：timestamp.nonce.event.body（数据源请求event为空）
+func signContent(timestamp string, nonce string, event string, body []byte) string {
+	return fmt.Sprintf("%s.%s.%s.%s", timestamp, nonce, event, body)
+}
+
+// 校验签名，密钥为空时不校验
+func (w *Webhook) verifySignature(timestamp string, nonce string, signature string, event string, body []byte) error {
+	cfg := base.GetConfig().Webhook
+	if cfg.Secret == "" {
+		return nil
+	}
+	if timestamp == "" || nonce == "" || signature == "" {
+		return errInvalidSignature
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errInvalidSignature
+	}
+	diff := time.Since(time.Unix(ts, 0))
+	if diff > cfg.SignExpire || diff < -cfg.SignExpire {
+		return errSignExpired
+	}
+	expectSignature := util.HmacSha256(signContent(timestamp, nonce, event, body), cfg.Secret)
+	if !hmac.Equal([]byte(expectSignature), []byte(signature)) {
+		return errInvalidSignature
+	}
+
+	// 有效期内nonce只能使用一次，防止重放
+	nonceKey := fmt.Sprintf("webhookNonce:%s", nonce)
+	count, err := w.ctx.GetRedisConn().Incr(nonceKey)
+	if err != nil {
+		return err
+	}
+	if count == 1 {
+		// 时间戳允许前后偏差，nonce需要保留两倍有效期
+		if err := w.ctx.GetRedisConn().Expire(nonceKey, cfg.SignExpire*2); err != nil {
+			return err
+		}
+	}
+	if count > 1 {
+		return errNonceReused
+	}
+	return nil
+}
+
+// webhook和数据源的签名认证中间件
+func (w *Webhook) authMiddleware(c *wkhttp.Context) {
+	if base.GetConfig().Webhook.Secret == "" {
+		c.Next()
+		return
+	}
+	body, err := c.GetRawData()
+	if err != nil {
+		w.Error("读取数据失败！", zap.Error(err))
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"msg": "读取数据失败！",
+		})
+		return
+	}
+	// 放回body，后续处理还需要读取
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	err = w.verifySignature(c.GetHeader(headerTimestamp), c.GetHeader(headerNonce), c.GetHeader(headerSignature), c.Query("event"), body)
+	if err != nil && !isSignatureError(err) {
+		w.Error("webhook签名校验出错！", zap.Error(err), zap.String("path", c.Request.URL.Path))
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"msg": "签名校验出错！",
+		})
+		return
+	}
+	if err != nil {
+		w.Warn("webhook签名校验失败！", zap.Error(err), zap.String("path", c.Request.URL.Path), zap.String("ip", c.ClientIP()))
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"msg": err.Error(),
+		})
+		return
+	}
+	c.Next()
+}
+
+// grpc的签名认证拦截器
+func (w *Webhook) authUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if base.GetConfig().Webhook.Secret == "" {
+		return handler(ctx, req)
+	}
+	eventReq, ok := req.(*wkhook.EventReq)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, errInvalidSignature.Error())
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	getMD := func(key string) string {
+		values := md.Get(key)
+		if len(values) == 0 {
+			return ""
+		}
+		return values[0]
+	}
+	err := w.verifySignature(getMD(headerTimestamp), getMD(headerNonce), getMD(headerSignature), eventReq.Event, eventReq.Data)
+	if err != nil && !isSignatureError(err) {
+		w.Error("grpc webhook签名校验出错！", zap.Error(err), zap.String("method", info.FullMethod))
+		return nil, status.Error(codes.Internal, "签名校验出错！")
+	}
+	if err != nil {
+		w.Warn("grpc webhook签名校验失败！", zap.Error(err), zap.String("method", info.FullMethod))
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return handler(ctx, req)
+}
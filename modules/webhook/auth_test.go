@@ -0,0 +1,126 @@
+package webhook
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/log"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/wkhttp"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/testutil"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/base"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/pkg/util"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	vp := viper.New()
+	vp.Set("webhook.secret", "testsecret")
+	base.SetupConfig(vp)
+	defer base.SetupConfig(viper.New())
+
+	w := &Webhook{
+		Log: log.NewTLog("Webhook"),
+	}
+	r := wkhttp.New()
+	r.POST("/v1/datasource", w.authMiddleware, func(c *wkhttp.Context) {
+		c.ResponseOK()
+	})
+
+	body := []byte(`{"cmd":"getSystemUIDs"}`)
+	timestamp := fmt.Sprintf("%d", time.Now().Unix())
+
+	// 没有签名
+	rw := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/datasource", bytes.NewReader(body))
+	r.ServeHTTP(rw, req)
+	assert.Equal(t, http.StatusUnauthorized, rw.Code)
+
+	// 签名错误
+	rw = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/v1/datasource", bytes.NewReader(body))
+	req.Header.Set(headerTimestamp, timestamp)
+	req.Header.Set(headerNonce, "nonce1")
+	req.Header.Set(headerSignature, util.HmacSha256(signContent(timestamp, "nonce1", "", body), "wrongsecret"))
+	r.ServeHTTP(rw, req)
+	assert.Equal(t, http.StatusUnauthorized, rw.Code)
+
+	// 签名已过期
+	expiredTimestamp := fmt.Sprintf("%d", time.Now().Add(-time.Hour).Unix())
+	rw = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/v1/datasource", bytes.NewReader(body))
+	req.Header.Set(headerTimestamp, expiredTimestamp)
+	req.Header.Set(headerNonce, "nonce2")
+	req.Header.Set(headerSignature, util.HmacSha256(signContent(expiredTimestamp, "nonce2", "", body), "testsecret"))
+	r.ServeHTTP(rw, req)
+	assert.Equal(t, http.StatusUnauthorized, rw.Code)
+}
+
+func newSignedRequest(body []byte, nonce string, secret string) *http.Request {
+	timestamp := fmt.Sprintf("%d", time.Now().Unix())
+	req, _ := http.NewRequest("POST", "/v1/datasource", bytes.NewReader(body))
+	req.Header.Set(headerTimestamp, timestamp)
+	req.Header.Set(headerNonce, nonce)
+	req.Header.Set(headerSignature, util.HmacSha256(signContent(timestamp, nonce, "", body), secret))
+	return req
+}
+
+func TestAuthMiddlewareNonce(t *testing.T) {
+	_, ctx := testutil.NewTestServer()
+	vp := viper.New()
+	vp.Set("webhook.secret", "testsecret")
+	base.SetupConfig(vp)
+	defer base.SetupConfig(viper.New())
+
+	w := &Webhook{
+		Log: log.NewTLog("Webhook"),
+		ctx: ctx,
+	}
+	r := wkhttp.New()
+	r.POST("/v1/datasource", w.authMiddleware, func(c *wkhttp.Context) {
+		c.ResponseOK()
+	})
+	body := []byte(`{"cmd":"getSystemUIDs"}`)
+	nonce := fmt.Sprintf("nonce%d", time.Now().UnixNano())
+	err := ctx.GetRedisConn().Del(fmt.Sprintf("webhookNonce:%s", nonce))
+	assert.NoError(t, err)
+
+	// 签名正确
+	rw := httptest.NewRecorder()
+	r.ServeHTTP(rw, newSignedRequest(body, nonce, "testsecret"))
+	assert.Equal(t, http.StatusOK, rw.Code)
+
+	// nonce重复使用
+	rw = httptest.NewRecorder()
+	r.ServeHTTP(rw, newSignedRequest(body, nonce, "testsecret"))
+	assert.Equal(t, http.StatusUnauthorized, rw.Code)
+}
+
+func TestAuthMiddlewareRedisError(t *testing.T) {
+	cfg := config.New()
+	cfg.DB.RedisAddr = "127.0.0.1:1" // 无法连接的redis
+	ctx := testutil.NewTestContext(cfg)
+	vp := viper.New()
+	vp.Set("webhook.secret", "testsecret")
+	base.SetupConfig(vp)
+	defer base.SetupConfig(viper.New())
+
+	w := &Webhook{
+		Log: log.NewTLog("Webhook"),
+		ctx: ctx,
+	}
+	r := wkhttp.New()
+	r.POST("/v1/datasource", w.authMiddleware, func(c *wkhttp.Context) {
+		c.ResponseOK()
+	})
+
+	// redis出错不是签名错误，返回500
+	rw := httptest.NewRecorder()
+	r.ServeHTTP(rw, newSignedRequest([]byte(`{"cmd":"getSystemUIDs"}`), "nonce1", "testsecret"))
+	assert.Equal(t, http.StatusInternalServerError, rw.Code)
+}
@@ -0,0 +1,41 @@
+package webhook
+
+import (
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/base"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/pkg/pool"
+	"go.uber.org/zap"
+)
+
+const defaultEventPoolSize = 100
+
+// 将消息分发给匹配的消息处理者，处理者在任务池内异步执行
+func (w *Webhook) dispatchMessages(messages []*config.MessageResp) {
+	// 提前解析正文，避免处理者并发解析
+	for _, message := range messages {
+		if _, err := message.GetPayloadMap(); err != nil {
+			w.Warn("消息payload格式有误！", zap.Error(err), zap.Int64("messageID", message.MessageID))
+		}
+	}
+	for _, handler := range base.GetMessageHandlers() {
+		filtered := handler.Filter(messages)
+		if len(filtered) == 0 {
+			continue
+		}
+		w.messageCollector.Work <- &pool.Job{
+			Data: handler,
+			JobFunc: func(id int64, data interface{}) {
+				w.handleMessages(data.(base.MessageHandler), filtered)
+			},
+		}
+	}
+}
+
+func (w *Webhook) handleMessages(handler base.MessageHandler, messages []*config.MessageResp) {
+	defer func() {
+		if err := recover(); err != nil {
+			w.Error("消息处理者执行异常！", zap.Any("err", err), zap.String("handler", handler.Name))
+		}
+	}()
+	handler.Handle(messages)
+}
@@ -0,0 +1,79 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/common"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/log"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/base"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/pkg/pool"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDispatchMessages(t *testing.T) {
+	handled := make(chan []*config.MessageResp, 1)
+	base.AddMessageHandler(base.MessageHandler{
+		Name:         "test",
+		ContentTypes: []common.ContentType{common.Image},
+		Handle: func(messages []*config.MessageResp) {
+			select {
+			case handled <- messages:
+			default:
+			}
+		},
+	})
+	defer base.RemoveMessageHandler("test")
+	w := &Webhook{
+		Log:              log.NewTLog("Webhook"),
+		messageCollector: pool.StartDispatcher(1),
+	}
+	defer func() { w.messageCollector.End <- true }()
+	w.dispatchMessages([]*config.MessageResp{
+		{MessageID: 1, Payload: []byte(`{"type":1,"content":"hello"}`)},
+		{MessageID: 2, Payload: []byte(`{"type":2,"url":"a.png"}`)},
+	})
+
+	select {
+	case messages := <-handled:
+		assert.Equal(t, 1, len(messages))
+		assert.Equal(t, int64(2), messages[0].MessageID)
+	case <-time.After(time.Second * 5):
+		t.Fatal("消息处理超时")
+	}
+}
+
+func TestDispatchMessagesInvalidType(t *testing.T) {
+	handled := make(chan []*config.MessageResp, 1)
+	base.AddMessageHandler(base.MessageHandler{
+		Name:         "testInvalidType",
+		ContentTypes: []common.ContentType{common.Image},
+		Handle: func(messages []*config.MessageResp) {
+			select {
+			case handled <- messages:
+			default:
+			}
+		},
+	})
+	defer base.RemoveMessageHandler("testInvalidType")
+	w := &Webhook{
+		Log:              log.NewTLog("Webhook"),
+		messageCollector: pool.StartDispatcher(1),
+	}
+	defer func() { w.messageCollector.End <- true }()
+	// 没有type和type不是数字的消息不能导致panic
+	w.dispatchMessages([]*config.MessageResp{
+		{MessageID: 1, Payload: []byte(`{"content":"hello"}`)},
+		{MessageID: 2, Payload: []byte(`{"type":"2","url":"a.png"}`)},
+		{MessageID: 3, Payload: []byte(`{"type":2,"url":"a.png"}`)},
+	})
+
+	select {
+	case messages := <-handled:
+		assert.Equal(t, 1, len(messages))
+		assert.Equal(t, int64(3), messages[0].MessageID)
+	case <-time.After(time.Second * 5):
+		t.Fatal("消息处理超时")
+	}
+}
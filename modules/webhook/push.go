@@ -0,0 +1,234 @@
+package webhook
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/common"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/util"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/user"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/pkg/pool"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/pkg/push"
+	"go.uber.org/zap"
+)
+
+const defaultPushPoolSize = 100
+
+// 推送内容不显示详情时的默认内容
+const defaultPushContent = "您有一条新消息"
+
+// 非文本消息推送时显示的内容
+var pushContentPlaceholders = map[common.ContentType]string{
+	common.Image:           "[图片]",
+	common.GIF:             "[GIF]",
+	common.Voice:           "[语音]",
+	common.Video:           "[视频]",
+	common.Location:        "[位置]",
+	common.Card:            "[名片]",
+	common.File:            "[文件]",
+	common.VectorSticker:   "[贴图]",
+	common.EmojiSticker:    "[表情]",
+	common.RichText:        "[富文本]",
+	common.MultipleForward: "[聊天记录]",
+}
+
+// msgOfflineNotify IM的离线消息通知
+type msgOfflineNotify struct {
+	config.MessageResp
+	ToUIDs          []string `json:"to_uids"`          // 离线的接收者
+	Compress        string   `json:"compress"`         // 接收者的压缩方式，为空表示不压缩
+	CompresssToUIDs []byte   `json:"compress_to_uids"` // 压缩后的接收者
+}
+
+// 获取离线的接收者
+func (m *msgOfflineNotify) getToUIDs() ([]string, error) {
+	if m.Compress == "" {
+		return m.ToUIDs, nil
+	}
+	if m.Compress != "jsonGzip" {
+		return nil, errors.New("不支持的压缩方式！")
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(m.CompresssToUIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	var toUIDs []string
+	if err := util.ReadJsonByByte(data, &toUIDs); err != nil {
+		return nil, err
+	}
+	return toUIDs, nil
+}
+
+// 根据配置创建推送厂商，未配置的厂商不创建
+func (w *Webhook) newPushProviders() map[string]push.Provider {
+	providers := map[string]push.Provider{}
+	pushCfg := w.ctx.GetConfig().Push
+	if pushCfg.APNS.Cert != "" {
+		apns, err := push.NewAPNS(pushCfg.APNS)
+		if err != nil {
+			w.Error("创建苹果推送失败！", zap.Error(err))
+		} else {
+			providers[push.DeviceTypeIOS] = apns
+		}
+	}
+	if pushCfg.HMS.AppID != "" {
+		providers[push.DeviceTypeHMS] = push.NewHMS(pushCfg.HMS)
+	}
+	if pushCfg.MI.AppSecret != "" {
+		providers[push.DeviceTypeMI] = push.NewMI(pushCfg.MI)
+	}
+	if pushCfg.VIVO.AppID != "" {
+		providers[push.DeviceTypeVIVO] = push.NewVIVO(pushCfg.VIVO)
+	}
+	if pushCfg.OPPO.AppKey != "" {
+		providers[push.DeviceTypeOPPO] = push.NewOPPO(pushCfg.OPPO)
+	}
+	if pushCfg.FIREBASE.JsonPath != "" {
+		firebase, err := push.NewFIREBASE(pushCfg.FIREBASE)
+		if err != nil {
+			w.Error("创建firebase推送失败！", zap.Error(err))
+		} else {
+			providers[push.DeviceTypeFIREBASE] = firebase
+		}
+	}
+	return providers
+}
+
+// 处理离线消息，推送给离线用户
+func (w *Webhook) handleMsgOffline(data []byte) error {
+	var msg msgOfflineNotify
+	if err := util.ReadJsonByByte(data, &msg); err != nil {
+		return err
+	}
+	if msg.Header.RedDot == 0 { // 不显示红点的消息不推送（例如cmd消息）
+		return nil
+	}
+	toUIDs, err := msg.getToUIDs()
+	if err != nil {
+		return err
+	}
+	toUIDs = removeUID(toUIDs, msg.FromUID)
+	if len(toUIDs) == 0 || len(w.pushProviders) == 0 {
+		return nil
+	}
+
+	// 对方看到的频道
+	channelID := msg.ChannelID
+	if msg.ChannelType == common.ChannelTypePerson.Uint8() {
+		channelID = msg.FromUID
+	}
+	muteUIDs, err := w.messageService.GetMuteUIDs(toUIDs, channelID, msg.ChannelType)
+	if err != nil {
+		return err
+	}
+	for _, muteUID := range muteUIDs {
+		toUIDs = removeUID(toUIDs, muteUID)
+	}
+	if len(toUIDs) == 0 {
+		return nil
+	}
+	devices, err := w.userService.GetDevicesWithUIDs(toUIDs)
+	if err != nil {
+		return err
+	}
+	if len(devices) == 0 {
+		return nil
+	}
+	payload := w.newPushPayload(&msg.MessageResp, channelID)
+	for _, device := range devices {
+		w.dispatchPush(device, payload)
+	}
+	return nil
+}
+
+// 生成推送内容
+func (w *Webhook) newPushPayload(msg *config.MessageResp, channelID string) *push.Payload {
+	payload := &push.Payload{
+		ChannelID:   channelID,
+		ChannelType: msg.ChannelType,
+		Content:     defaultPushContent,
+	}
+	var err error
+	if msg.ChannelType == common.ChannelTypeGroup.Uint8() {
+		payload.Title, err = w.groupService.GetGroupName(msg.ChannelID)
+	} else {
+		payload.Title, err = w.userService.GetUserName(msg.FromUID)
+	}
+	if err != nil {
+		w.Warn("查询推送标题失败！", zap.Error(err), zap.String("channelID", msg.ChannelID))
+	}
+	if !w.ctx.GetConfig().Push.ContentDetailOn {
+		return payload
+	}
+	payloadMap, err := msg.GetPayloadMap()
+	if err != nil {
+		w.Warn("解析消息正文失败！", zap.Error(err), zap.Int64("messageID", msg.MessageID))
+		return payload
+	}
+	contentTypeNum, _ := payloadMap["type"].(json.Number)
+	contentTypeI64, _ := contentTypeNum.Int64()
+	contentType := common.ContentType(contentTypeI64)
+	if contentType == common.Text {
+		if content, _ := payloadMap["content"].(string); content != "" {
+			payload.Content = content
+		}
+	} else if placeholder, ok := pushContentPlaceholders[contentType]; ok {
+		payload.Content = placeholder
+	}
+	if msg.ChannelType == common.ChannelTypeGroup.Uint8() && payload.Content != defaultPushContent {
+		if fromName, _ := w.userService.GetUserName(msg.FromUID); fromName != "" {
+			payload.Content = fromName + ": " + payload.Content
+		}
+	}
+	return payload
+}
+
+// 提交推送任务到推送池
+func (w *Webhook) dispatchPush(device *user.DeviceResp, payload *push.Payload) {
+	provider := w.pushProviders[device.DeviceType]
+	if provider == nil {
+		w.Debug("设备类型未配置推送", zap.String("uid", device.UID), zap.String("deviceType", device.DeviceType))
+		return
+	}
+	w.pushCollector.Work <- &pool.Job{
+		Data: device,
+		JobFunc: func(id int64, data interface{}) {
+			w.pushToDevice(provider, data.(*user.DeviceResp), payload)
+		},
+	}
+}
+
+// 推送到设备，token失效的设备直接删除
+func (w *Webhook) pushToDevice(provider push.Provider, device *user.DeviceResp, payload *push.Payload) {
+	err := provider.Push(device.DeviceToken, payload)
+	if err == nil {
+		return
+	}
+	if errors.Is(err, push.ErrInvalidToken) {
+		w.Info("设备token已失效，删除设备", zap.String("uid", device.UID), zap.String("deviceType", device.DeviceType))
+		if err := w.userService.DeleteDevice(device.UID, device.DeviceToken); err != nil {
+			w.Error("删除失效设备失败！", zap.Error(err), zap.String("uid", device.UID))
+		}
+		return
+	}
+	w.Error("推送失败！", zap.Error(err), zap.String("uid", device.UID), zap.String("provider", provider.Name()))
+}
+
+func removeUID(uids []string, uid string) []string {
+	newUIDs := make([]string, 0, len(uids))
+	for _, u := range uids {
+		if u != uid {
+			newUIDs = append(newUIDs, u)
+		}
+	}
+	return newUIDs
+}
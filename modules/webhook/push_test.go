@@ -0,0 +1,65 @@
+package webhook
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+	"time"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/pkg/log"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/modules/user"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/pkg/pool"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/pkg/push"
+	"github.com/stretchr/testify/assert"
+)
+
+type testProvider struct {
+	pushed chan string
+}
+
+func (t *testProvider) Name() string {
+	return push.DeviceTypeMI
+}
+
+func (t *testProvider) Push(deviceToken string, payload *push.Payload) error {
+	t.pushed <- deviceToken + ":" + payload.Content
+	return nil
+}
+
+func TestMsgOfflineGetToUIDs(t *testing.T) {
+	var buff bytes.Buffer
+	writer := gzip.NewWriter(&buff)
+	_, err := writer.Write([]byte(`["u1","u2"]`))
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+
+	msg := &msgOfflineNotify{
+		Compress:        "jsonGzip",
+		CompresssToUIDs: buff.Bytes(),
+	}
+	toUIDs, err := msg.getToUIDs()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u1", "u2"}, toUIDs)
+}
+
+func TestDispatchPush(t *testing.T) {
+	provider := &testProvider{pushed: make(chan string, 1)}
+	w := &Webhook{
+		Log: log.NewTLog("Webhook"),
+		pushProviders: map[string]push.Provider{
+			push.DeviceTypeMI: provider,
+		},
+		pushCollector: pool.StartDispatcher(1),
+	}
+	defer func() { w.pushCollector.End <- true }()
+	w.dispatchPush(&user.DeviceResp{UID: "u1", DeviceType: push.DeviceTypeMI, DeviceToken: "token1"}, &push.Payload{Content: "hello"})
+	// 未配置推送的设备类型不推送
+	w.dispatchPush(&user.DeviceResp{UID: "u2", DeviceType: push.DeviceTypeHMS, DeviceToken: "token2"}, &push.Payload{Content: "hello"})
+
+	select {
+	case pushed := <-provider.pushed:
+		assert.Equal(t, "token1:hello", pushed)
+	case <-time.After(time.Second * 5):
+		t.Fatal("推送超时")
+	}
+}
@@ -0,0 +1,254 @@
+package imclient
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/pkg/util"
+	"github.com/tidwall/gjson"
+)
+
+// Client 悟空IM的api客户端
+// 统一处理认证头、超时、重试以及错误解析
+type Client struct {
+	opts       *Options
+	httpClient *http.Client
+}
+
+// New 创建悟空IM客户端
+func New(opts *Options) *Client {
+	if opts == nil {
+		opts = NewOptions()
+	}
+	return &Client{
+		opts: opts,
+		httpClient: &http.Client{
+			Timeout: opts.Timeout,
+		},
+	}
+}
+
+// APIURL 悟空IM的api地址
+func (c *Client) APIURL() string {
+	return c.opts.APIURL
+}
+
+// SendMessage 发送消息
+func (c *Client) SendMessage(req *config.MsgSendReq) error {
+	_, err := c.SendMessageWithResult(req)
+	return err
+}
+
+// SendMessageWithResult 发送消息并返回消息ID等信息
+// 发送消息不是幂等操作，所以不做重试，防止产生重复消息
+func (c *Client) SendMessageWithResult(req *config.MsgSendReq) (*config.MsgSendResp, error) {
+	body, err := c.post("/message/send", req, false)
+	if err != nil {
+		return nil, err
+	}
+	dataResult := gjson.GetBytes(body, "data")
+	return &config.MsgSendResp{
+		MessageID:   dataResult.Get("message_id").Int(),
+		MessageSeq:  uint32(dataResult.Get("message_seq").Int()),
+		ClientMsgNo: dataResult.Get("client_msg_no").String(),
+	}, nil
+}
+
+// SetUnread 设置最近会话未读数
+func (c *Client) SetUnread(req *config.ClearConversationUnreadReq) error {
+	_, err := c.post("/conversations/setUnread", req, true)
+	return err
+}
+
+// DeleteConversation 删除用户的某个最近会话
+func (c *Client) DeleteConversation(req *config.DeleteConversationReq) error {
+	_, err := c.post("/conversations/delete", req, true)
+	return err
+}
+
+// SyncConversations 同步用户的最近会话
+func (c *Client) SyncConversations(req *SyncConversationsReq) ([]*config.SyncUserConversationResp, error) {
+	body, err := c.post("/conversation/sync", req, true)
+	if err != nil {
+		return nil, err
+	}
+	var conversations []*config.SyncUserConversationResp
+	if err = util.ReadJsonByByte(body, &conversations); err != nil {
+		return nil, err
+	}
+	return conversations, nil
+}
+
+// SyncChannelMessages 同步频道消息
+func (c *Client) SyncChannelMessages(req *config.SyncChannelMessageReq) (*config.SyncChannelMessageResp, error) {
+	body, err := c.post("/channel/messagesync", req, true)
+	if err != nil {
+		return nil, err
+	}
+	var resp *config.SyncChannelMessageResp
+	if err = util.ReadJsonByByte(body, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetMessagesWithSeqs 获取频道内指定序列号的消息
+func (c *Client) GetMessagesWithSeqs(channelID string, channelType uint8, loginUID string, seqs []uint32) (*config.SyncChannelMessageResp, error) {
+	return c.SearchMessages(&config.MsgSearchReq{
+		LoginUID:    loginUID,
+		ChannelID:   channelID,
+		ChannelType: channelType,
+		MessageSeqs: seqs,
+	})
+}
+
+// SearchMessages 按消息序列号、消息ID或客户端消息编号查询频道内的消息
+func (c *Client) SearchMessages(req *config.MsgSearchReq) (*config.SyncChannelMessageResp, error) {
+	body, err := c.post("/messages", req, true)
+	if err != nil {
+		return nil, err
+	}
+	var resp *config.SyncChannelMessageResp
+	if err = util.ReadJsonByByte(body, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// AddSubscribers 添加频道订阅者
+func (c *Client) AddSubscribers(req *config.SubscriberAddReq) error {
+	_, err := c.post("/channel/subscriber_add", req, true)
+	return err
+}
+
+// RemoveSubscribers 移除频道订阅者
+func (c *Client) RemoveSubscribers(req *config.SubscriberRemoveReq) error {
+	_, err := c.post("/channel/subscriber_remove", req, true)
+	return err
+}
+
+// BlacklistAdd 添加频道黑名单
+func (c *Client) BlacklistAdd(req *config.ChannelBlacklistReq) error {
+	_, err := c.post("/channel/blacklist_add", req, true)
+	return err
+}
+
+// BlacklistRemove 移除频道黑名单
+func (c *Client) BlacklistRemove(req *config.ChannelBlacklistReq) error {
+	_, err := c.post("/channel/blacklist_remove", req, true)
+	return err
+}
+
+// WhitelistSet 设置频道白名单（覆盖旧的数据）
+func (c *Client) WhitelistSet(req *config.ChannelWhitelistReq) error {
+	_, err := c.post("/channel/whitelist_set", req, true)
+	return err
+}
+
+// UpdateToken 将用户token注册到悟空IM，如果存在则更新
+func (c *Client) UpdateToken(req *config.UpdateIMTokenReq) (*config.UpdateIMTokenResp, error) {
+	body, err := c.post("/user/token", map[string]interface{}{
+		"uid":          req.UID,
+		"token":        req.Token,
+		"device_level": req.DeviceLevel,
+		"device_flag":  req.DeviceFlag,
+	}, true)
+	if err != nil {
+		return nil, err
+	}
+	var resp *config.UpdateIMTokenResp
+	if err = util.ReadJsonByByte(body, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Route 获取客户端连接IM的地址
+func (c *Client) Route() (*RouteResp, error) {
+	body, err := c.get("/route", nil, true)
+	if err != nil {
+		return nil, err
+	}
+	var resp *RouteResp
+	if err = util.ReadJsonByByte(body, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) post(path string, req interface{}, idempotent bool) ([]byte, error) {
+	return c.do(http.MethodPost, path, nil, []byte(util.ToJson(req)), idempotent)
+}
+
+func (c *Client) get(path string, query url.Values, idempotent bool) ([]byte, error) {
+	return c.do(http.MethodGet, path, query, nil, idempotent)
+}
+
+// do 执行请求，幂等请求在网络错误或服务端5xx时按配置重试
+func (c *Client) do(method string, path string, query url.Values, body []byte, idempotent bool) ([]byte, error) {
+	attempts := 1
+	if idempotent && c.opts.MaxRetries > 0 {
+		attempts += c.opts.MaxRetries
+	}
+	backoff := c.opts.RetryBackoff
+	var err error
+	for i := 0; i < attempts; i++ {
+		if i > 0 && backoff > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		var (
+			statusCode int
+			respBody   []byte
+		)
+		statusCode, respBody, err = c.send(method, path, query, body)
+		if err != nil {
+			continue
+		}
+		if statusCode == http.StatusOK {
+			return respBody, nil
+		}
+		err = decodeError(path, statusCode, respBody)
+		if statusCode < http.StatusInternalServerError {
+			break
+		}
+	}
+	return nil, err
+}
+
+func (c *Client) send(method string, path string, query url.Values, body []byte) (int, []byte, error) {
+	reqURL := strings.TrimSuffix(c.opts.APIURL, "/") + path
+	if len(query) > 0 {
+		reqURL = fmt.Sprintf("%s?%s", reqURL, query.Encode())
+	}
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, reqURL, bodyReader)
+	if err != nil {
+		return 0, nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.opts.ManagerToken != "" {
+		req.Header.Set("token", c.opts.ManagerToken)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, respBody, nil
+}
@@ -0,0 +1,123 @@
+package imclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClient(url string) *Client {
+	opts := NewOptions()
+	opts.APIURL = url
+	opts.ManagerToken = "manager"
+	opts.Timeout = time.Second
+	opts.RetryBackoff = time.Millisecond
+	return New(opts)
+}
+
+func TestSendMessageWithResult(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/message/send", r.URL.Path)
+		assert.Equal(t, "manager", r.Header.Get("token"))
+		w.Write([]byte(`{"data":{"message_id":100,"message_seq":2,"client_msg_no":"c1"}}`))
+	}))
+	defer s.Close()
+
+	resp, err := newTestClient(s.URL).SendMessageWithResult(&config.MsgSendReq{ChannelID: "g1", ChannelType: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), resp.MessageID)
+	assert.Equal(t, uint32(2), resp.MessageSeq)
+	assert.Equal(t, "c1", resp.ClientMsgNo)
+}
+
+func TestErrorDecode(t *testing.T) {
+	var count int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"msg":"频道不存在","status":400}`))
+	}))
+	defer s.Close()
+
+	err := newTestClient(s.URL).AddSubscribers(&config.SubscriberAddReq{ChannelID: "g1", ChannelType: 2})
+	assert.Error(t, err)
+	imErr, ok := err.(*Error)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusBadRequest, imErr.StatusCode)
+	assert.Equal(t, "频道不存在", imErr.Msg)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count)) // 4xx不重试
+}
+
+func TestRetry(t *testing.T) {
+	var count int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"tcp_addr":"127.0.0.1:5100","ws_addr":"ws://127.0.0.1:5200"}`))
+	}))
+	defer s.Close()
+
+	resp, err := newTestClient(s.URL).Route()
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:5100", resp.TCPAddr)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&count))
+}
+
+func TestSendMessageNoRetry(t *testing.T) {
+	var count int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer s.Close()
+
+	err := newTestClient(s.URL).SendMessage(&config.MsgSendReq{ChannelID: "g1", ChannelType: 2})
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+}
+
+func TestGetMessagesWithSeqs(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/messages", r.URL.Path)
+		w.Write([]byte(`{"messages":[{"message_id":100,"message_seq":2,"from_uid":"u1"}]}`))
+	}))
+	defer s.Close()
+
+	resp, err := newTestClient(s.URL).GetMessagesWithSeqs("g1", 2, "", []uint32{2})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(resp.Messages))
+	assert.Equal(t, "u1", resp.Messages[0].FromUID)
+}
+
+func TestDeleteConversation(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/conversations/delete", r.URL.Path)
+		w.Write([]byte(`{"status":200}`))
+	}))
+	defer s.Close()
+
+	err := newTestClient(s.URL).DeleteConversation(&config.DeleteConversationReq{UID: "u1", ChannelID: "g1", ChannelType: 2})
+	assert.NoError(t, err)
+}
+
+func TestSetUnread(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/conversations/setUnread", r.URL.Path)
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"uid":"u1","channel_id":"g1","channel_type":2,"unread":0,"message_seq":0}`, string(body))
+		w.Write([]byte(`{"status":200}`))
+	}))
+	defer s.Close()
+
+	err := newTestClient(s.URL).SetUnread(&config.ClearConversationUnreadReq{UID: "u1", ChannelID: "g1", ChannelType: 2})
+	assert.NoError(t, err)
+}
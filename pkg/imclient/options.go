@@ -0,0 +1,22 @@
+package imclient
+
+import "time"
+
+// Options 悟空IM客户端配置
+type Options struct {
+	APIURL       string        // 悟空IM的api地址 格式： http://xx.xx.xx.xx:5001
+	ManagerToken string        // 悟空IM的管理者token 悟空IM配置了就需要填写，没配置就不需要
+	Timeout      time.Duration // 单次请求超时时间
+	MaxRetries   int           // 失败重试次数（只针对网络错误和5xx，且只对幂等请求生效）
+	RetryBackoff time.Duration // 重试间隔（每次重试翻倍）
+}
+
+// NewOptions 默认配置
+func NewOptions() *Options {
+	return &Options{
+		APIURL:       "http://127.0.0.1:5001",
+		Timeout:      time.Second * 10,
+		MaxRetries:   2,
+		RetryBackoff: time.Millisecond * 200,
+	}
+}
@@ -0,0 +1,46 @@
+package imclient
+
+import (
+	"fmt"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/tidwall/gjson"
+)
+
+// Error 悟空IM返回的错误
+type Error struct {
+	Path       string // 请求路径
+	StatusCode int    // http状态码
+	Msg        string // IM返回的错误信息
+}
+
+func (e *Error) Error() string {
+	if e.Msg != "" {
+		return fmt.Sprintf("IM服务[%s]失败！ -> %s", e.Path, e.Msg)
+	}
+	return fmt.Sprintf("IM服务[%s]返回状态[%d]失败！", e.Path, e.StatusCode)
+}
+
+func decodeError(path string, statusCode int, body []byte) error {
+	return &Error{
+		Path:       path,
+		StatusCode: statusCode,
+		Msg:        gjson.GetBytes(body, "msg").String(),
+	}
+}
+
+// SyncConversationsReq 同步最近会话请求
+type SyncConversationsReq struct {
+	UID         string            `json:"uid"`           // 用户uid
+	Version     int64             `json:"version"`       // 当前客户端的会话最大版本号
+	LastMsgSeqs string            `json:"last_msg_seqs"` // 客户端所有会话的最后一条消息序列号 格式： channelID:channelType:last_msg_seq|channelID:channelType:last_msg_seq
+	MsgCount    int64             `json:"msg_count"`     // 每个会话消息数量
+	Larges      []*config.Channel `json:"larges"`        // 超大群频道集合
+}
+
+// RouteResp IM连接地址
+type RouteResp struct {
+	TCPAddr string `json:"tcp_addr"` // TCP地址
+	WSAddr  string `json:"ws_addr"`  // WebSocket地址
+	WSSAddr string `json:"wss_addr"` // WebSocket Secure地址
+}
@@ -4,18 +4,18 @@ import (
 	"sync/atomic"
 )
 
-var WorkerChannel = make(chan chan *Job)
-
 type JobStatistics struct {
 	Executing int64 // Total number of jobs executed
 	Total     int64
 }
 
 type Collector struct {
-	Work  chan *Job // receives jobs to send to workers
-	End   chan bool // when receives bool stops workers
-	jobS  *JobStatistics
-	queue *Queue
+	Work          chan *Job // receives jobs to send to workers
+	End           chan bool // when receives bool stops workers
+	jobS          *JobStatistics
+	queue         *Queue
+	workerChannel chan chan *Job // idle workers of this collector only
+	stopped       chan struct{}  // closed when the collector stops
 }
 
 func StartDispatcher(workerCount int64) Collector {
@@ -23,14 +23,15 @@ func StartDispatcher(workerCount int64) Collector {
 	var workers []Worker
 	input := make(chan *Job) // channel to recieve work
 	end := make(chan bool)   // channel to spin down workers
-	jobFinished := make(chan bool)
+
 	collector := Collector{
-		Work:  input,
-		End:   end,
-		jobS:  &JobStatistics{},
-		queue: NewQueue(),
+		Work:          input,
+		End:           end,
+		jobS:          &JobStatistics{},
+		queue:         NewQueue(),
+		workerChannel: make(chan chan *Job),
+		stopped:       make(chan struct{}),
 	}
-
 	go collector.loopPop()
 
 	for i < workerCount {
@@ -38,27 +39,21 @@ func StartDispatcher(workerCount int64) Collector {
 		worker := Worker{
 			ID:            i,
 			Channel:       make(chan *Job),
-			WorkerChannel: WorkerChannel,
+			WorkerChannel: collector.workerChannel,
 			End:           make(chan struct{}),
-			jobFinished:   jobFinished,
+			jobS:          collector.jobS,
 		}
 		worker.Start()
 		workers = append(workers, worker) // stores worker
 	}
-	go func() {
-		for {
-			select {
-			case <-jobFinished: // job finished
-				atomic.AddInt64(&collector.jobS.Executing, -1)
-			}
-		}
-	}()
 
 	// start collector
 	go func() {
 		for {
 			select {
 			case <-end:
+				close(collector.stopped)
+				collector.queue.Close()
 				for _, w := range workers {
 					w.Stop() // stop worker
 				}
@@ -75,12 +70,23 @@ func StartDispatcher(workerCount int64) Collector {
 func (c Collector) loopPop() {
 	for {
 		jobObj := c.queue.Pop()
+		if jobObj == nil { // queue closed
+			return
+		}
 		atomic.AddInt64(&c.jobS.Total, 1)
-		worker := <-WorkerChannel // wait for available channel
+		var worker chan *Job
+		select {
+		case worker = <-c.workerChannel: // wait for available channel
+		case <-c.stopped:
+			return
+		}
 		atomic.AddInt64(&c.jobS.Executing, 1)
-		worker <- jobObj.(*Job) // dispatch work to worker
+		select {
+		case worker <- jobObj.(*Job): // dispatch work to worker
+		case <-c.stopped:
+			return
+		}
 	}
-
 }
 
 func (c Collector) GetStatistics() *JobStatistics {
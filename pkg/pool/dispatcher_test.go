@@ -0,0 +1,41 @@
+package pool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectorWorkersIsolated(t *testing.T) {
+	other := StartDispatcher(1) // 空闲的其他任务池，不能处理本任务池的任务
+	defer func() { other.End <- true }()
+	collector := StartDispatcher(1)
+	defer func() { collector.End <- true }()
+
+	release := make(chan struct{})
+	started := make(chan int, 2)
+	for i := 1; i <= 2; i++ {
+		collector.Work <- &Job{
+			Data: i,
+			JobFunc: func(id int64, data interface{}) {
+				started <- data.(int)
+				<-release
+			},
+		}
+	}
+	assert.Equal(t, 1, <-started)
+	// 只有一个worker，第一个任务未完成前第二个任务不能执行
+	select {
+	case <-started:
+		t.Fatal("任务在其他任务池的worker上执行了")
+	case <-time.After(time.Millisecond * 100):
+	}
+	close(release)
+	select {
+	case i := <-started:
+		assert.Equal(t, 2, i)
+	case <-time.After(time.Second):
+		t.Fatal("任务执行超时")
+	}
+}
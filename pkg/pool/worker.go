@@ -2,6 +2,7 @@ package pool
 
 import (
 	"log"
+	"sync/atomic"
 )
 
 type JobFunc func(id int64, data interface{})
@@ -16,19 +17,23 @@ type Worker struct {
 	WorkerChannel chan chan *Job // used to communicate between dispatcher and workers
 	Channel       chan *Job
 	End           chan struct{}
-	jobFinished   chan bool
+	jobS          *JobStatistics
 }
 
 // start worker
 func (w *Worker) Start() {
 	go func() {
 		for {
-			w.WorkerChannel <- w.Channel // when the worker is available place channel in queue
+			select {
+			case w.WorkerChannel <- w.Channel: // when the worker is available place channel in queue
+			case <-w.End:
+				return
+			}
 			select {
 			case job := <-w.Channel: // worker has received job
 				if job != nil {
 					job.JobFunc(w.ID, job.Data) // do work
-					w.jobFinished <- true
+					atomic.AddInt64(&w.jobS.Executing, -1)
 				}
 
 			case <-w.End:
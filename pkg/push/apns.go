@@ -0,0 +1,91 @@
+package push
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/pkg/util"
+	"github.com/tidwall/gjson"
+	"golang.org/x/crypto/pkcs12"
+)
+
+const (
+	apnsHostDev  = "https://api.sandbox.push.apple.com"
+	apnsHostProd = "https://api.push.apple.com"
+)
+
+// APNS 苹果推送（证书方式）
+type APNS struct {
+	cfg    config.APNSPush
+	host   string
+	client *http.Client
+}
+
+// NewAPNS 创建苹果推送，cert为p12证书路径
+func NewAPNS(cfg config.APNSPush) (*APNS, error) {
+	p12, err := os.ReadFile(cfg.Cert)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, cert, err := pkcs12.Decode(p12, cfg.Password)
+	if err != nil {
+		return nil, err
+	}
+	host := apnsHostProd
+	if cfg.Dev {
+		host = apnsHostDev
+	}
+	return &APNS{
+		cfg:  cfg,
+		host: host,
+		client: &http.Client{
+			Timeout: httpClient.Timeout,
+			Transport: &http.Transport{
+				ForceAttemptHTTP2: true,
+				TLSClientConfig: &tls.Config{
+					Certificates: []tls.Certificate{{
+						Certificate: [][]byte{cert.Raw},
+						PrivateKey:  privateKey,
+						Leaf:        cert,
+					}},
+				},
+			},
+		},
+	}, nil
+}
+
+func (a *APNS) Name() string {
+	return DeviceTypeIOS
+}
+
+func (a *APNS) Push(deviceToken string, payload *Payload) error {
+	body := map[string]interface{}{
+		"aps": map[string]interface{}{
+			"alert": map[string]interface{}{
+				"title": payload.Title,
+				"body":  payload.Content,
+			},
+			"sound": "default",
+		},
+		"channel_id":   payload.ChannelID,
+		"channel_type": payload.ChannelType,
+	}
+	statusCode, respBody, err := doRequest(a.client, http.MethodPost, fmt.Sprintf("%s/3/device/%s", a.host, deviceToken), "application/json", []byte(util.ToJson(body)), map[string]string{
+		"apns-topic":     a.cfg.Topic,
+		"apns-push-type": "alert",
+	})
+	if err != nil {
+		return err
+	}
+	if statusCode == http.StatusOK {
+		return nil
+	}
+	reason := gjson.GetBytes(respBody, "reason").String()
+	if statusCode == http.StatusGone || reason == "BadDeviceToken" || reason == "Unregistered" {
+		return ErrInvalidToken
+	}
+	return newPushError(a.Name(), statusCode, respBody)
+}
@@ -0,0 +1,151 @@
+package push
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/pkg/util"
+	"github.com/tidwall/gjson"
+)
+
+const firebaseScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+// FIREBASE firebase推送（HTTP v1接口，使用serviceAccount鉴权）
+type FIREBASE struct {
+	cfg        config.FIREBASEPush
+	account    *serviceAccount
+	privateKey *rsa.PrivateKey
+	pushURL    string
+	token      accessToken
+}
+
+type serviceAccount struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// NewFIREBASE 创建firebase推送，jsonPath为serviceAccount的JSON文件路径
+func NewFIREBASE(cfg config.FIREBASEPush) (*FIREBASE, error) {
+	data, err := os.ReadFile(cfg.JsonPath)
+	if err != nil {
+		return nil, err
+	}
+	var account *serviceAccount
+	if err = util.ReadJsonByByte(data, &account); err != nil {
+		return nil, err
+	}
+	if account == nil || account.PrivateKey == "" || account.ClientEmail == "" {
+		return nil, errors.New("serviceAccount的JSON文件格式有误！")
+	}
+	block, _ := pem.Decode([]byte(account.PrivateKey))
+	if block == nil {
+		return nil, errors.New("serviceAccount的私钥格式有误！")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("serviceAccount的私钥不是RSA私钥！")
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	projectID := cfg.ProjectId
+	if projectID == "" {
+		projectID = account.ProjectID
+	}
+	return &FIREBASE{
+		cfg:        cfg,
+		account:    account,
+		privateKey: privateKey,
+		pushURL:    fmt.Sprintf("https://fcm.googleapis.com/v1/projects/%s/messages:send", projectID),
+	}, nil
+}
+
+func (f *FIREBASE) Name() string {
+	return DeviceTypeFIREBASE
+}
+
+func (f *FIREBASE) Push(deviceToken string, payload *Payload) error {
+	token, err := f.token.get(f.auth)
+	if err != nil {
+		return err
+	}
+	body := map[string]interface{}{
+		"message": map[string]interface{}{
+			"token": deviceToken,
+			"notification": map[string]interface{}{
+				"title": payload.Title,
+				"body":  payload.Content,
+			},
+			"data": map[string]string{
+				"channel_id":   payload.ChannelID,
+				"channel_type": fmt.Sprintf("%d", payload.ChannelType),
+			},
+		},
+	}
+	statusCode, respBody, err := postJSON(f.pushURL, []byte(util.ToJson(body)), map[string]string{
+		"Authorization": "Bearer " + token,
+	})
+	if err != nil {
+		return err
+	}
+	if statusCode == http.StatusOK {
+		return nil
+	}
+	if statusCode == http.StatusUnauthorized {
+		f.token.reset()
+	}
+	if statusCode == http.StatusNotFound || gjson.GetBytes(respBody, "error.status").String() == "UNREGISTERED" {
+		return ErrInvalidToken
+	}
+	return newPushError(f.Name(), statusCode, respBody)
+}
+
+// 通过serviceAccount签名的JWT换取access_token
+func (f *FIREBASE) auth() (string, time.Duration, error) {
+	now := time.Now()
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims := base64.RawURLEncoding.EncodeToString([]byte(util.ToJson(map[string]interface{}{
+		"iss":   f.account.ClientEmail,
+		"scope": firebaseScope,
+		"aud":   f.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})))
+	signingInput := header + "." + claims
+	hashed := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, f.privateKey, crypto.SHA256, hashed[:])
+	if err != nil {
+		return "", 0, err
+	}
+	assertion := signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+	statusCode, respBody, err := postForm(f.account.TokenURI, url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}, nil)
+	if err != nil {
+		return "", 0, err
+	}
+	token := gjson.GetBytes(respBody, "access_token").String()
+	if token == "" {
+		return "", 0, newPushError(f.Name(), statusCode, respBody)
+	}
+	return token, time.Duration(gjson.GetBytes(respBody, "expires_in").Int()) * time.Second, nil
+}
@@ -0,0 +1,87 @@
+package push
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/pkg/util"
+	"github.com/tidwall/gjson"
+)
+
+// HMS 华为推送
+type HMS struct {
+	cfg     config.HMSPush
+	authURL string
+	pushURL string
+	token   accessToken
+}
+
+// NewHMS 创建华为推送
+func NewHMS(cfg config.HMSPush) *HMS {
+	return &HMS{
+		cfg:     cfg,
+		authURL: "https://oauth-login.cloud.huawei.com/oauth2/v3/token",
+		pushURL: "https://push-api.cloud.huawei.com",
+	}
+}
+
+func (h *HMS) Name() string {
+	return DeviceTypeHMS
+}
+
+func (h *HMS) Push(deviceToken string, payload *Payload) error {
+	token, err := h.token.get(h.auth)
+	if err != nil {
+		return err
+	}
+	body := map[string]interface{}{
+		"validate_only": false,
+		"message": map[string]interface{}{
+			"android": map[string]interface{}{
+				"notification": map[string]interface{}{
+					"title": payload.Title,
+					"body":  payload.Content,
+					"click_action": map[string]interface{}{
+						"type": 3, // 打开应用首页
+					},
+				},
+			},
+			"token": []string{deviceToken},
+		},
+	}
+	statusCode, respBody, err := postJSON(fmt.Sprintf("%s/v1/%s/messages:send", h.pushURL, h.cfg.AppID), []byte(util.ToJson(body)), map[string]string{
+		"Authorization": "Bearer " + token,
+	})
+	if err != nil {
+		return err
+	}
+	code := gjson.GetBytes(respBody, "code").String()
+	if code == "80000000" {
+		return nil
+	}
+	if code == "80200003" { // token过期
+		h.token.reset()
+	}
+	if code == "80300007" { // 所有token都无效
+		return ErrInvalidToken
+	}
+	return newPushError(h.Name(), statusCode, respBody)
+}
+
+func (h *HMS) auth() (string, time.Duration, error) {
+	statusCode, respBody, err := postForm(h.authURL, url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {h.cfg.AppID},
+		"client_secret": {h.cfg.AppSecret},
+	}, nil)
+	if err != nil {
+		return "", 0, err
+	}
+	token := gjson.GetBytes(respBody, "access_token").String()
+	if token == "" {
+		return "", 0, newPushError(h.Name(), statusCode, respBody)
+	}
+	return token, time.Duration(gjson.GetBytes(respBody, "expires_in").Int()) * time.Second, nil
+}
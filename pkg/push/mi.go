@@ -0,0 +1,53 @@
+package push
+
+import (
+	"net/url"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/tidwall/gjson"
+)
+
+// MI 小米推送
+type MI struct {
+	cfg     config.MIPush
+	pushURL string
+}
+
+// NewMI 创建小米推送
+func NewMI(cfg config.MIPush) *MI {
+	return &MI{
+		cfg:     cfg,
+		pushURL: "https://api.xmpush.xiaomi.com/v3/message/regid",
+	}
+}
+
+func (m *MI) Name() string {
+	return DeviceTypeMI
+}
+
+func (m *MI) Push(deviceToken string, payload *Payload) error {
+	values := url.Values{
+		"registration_id":         {deviceToken},
+		"restricted_package_name": {m.cfg.PackageName},
+		"title":                   {payload.Title},
+		"description":             {payload.Content},
+		"pass_through":            {"0"},
+		"notify_type":             {"-1"},
+	}
+	if m.cfg.ChannelID != "" {
+		values.Set("extra.channel_id", m.cfg.ChannelID)
+	}
+	statusCode, respBody, err := postForm(m.pushURL, values, map[string]string{
+		"Authorization": "key=" + m.cfg.AppSecret,
+	})
+	if err != nil {
+		return err
+	}
+	if gjson.GetBytes(respBody, "result").String() == "ok" {
+		return nil
+	}
+	if gjson.GetBytes(respBody, "code").Int() == 20301 { // 无效的regId
+		return ErrInvalidToken
+	}
+	return newPushError(m.Name(), statusCode, respBody)
+}
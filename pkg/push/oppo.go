@@ -0,0 +1,86 @@
+package push
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/pkg/util"
+	"github.com/tidwall/gjson"
+)
+
+// OPPO oppo推送
+type OPPO struct {
+	cfg     config.OPPOPush
+	baseURL string
+	token   accessToken
+}
+
+// NewOPPO 创建oppo推送
+func NewOPPO(cfg config.OPPOPush) *OPPO {
+	return &OPPO{
+		cfg:     cfg,
+		baseURL: "https://api.push.oppomobile.com",
+	}
+}
+
+func (o *OPPO) Name() string {
+	return DeviceTypeOPPO
+}
+
+func (o *OPPO) Push(deviceToken string, payload *Payload) error {
+	token, err := o.token.get(o.auth)
+	if err != nil {
+		return err
+	}
+	message := map[string]interface{}{
+		"target_type":  2, // registration_id推送
+		"target_value": deviceToken,
+		"notification": map[string]interface{}{
+			"title":             payload.Title,
+			"content":           payload.Content,
+			"click_action_type": 0, // 打开应用首页
+		},
+	}
+	statusCode, respBody, err := postForm(o.baseURL+"/server/v1/message/notification/unicast", url.Values{
+		"message": {util.ToJson(message)},
+	}, map[string]string{
+		"auth_token": token,
+	})
+	if err != nil {
+		return err
+	}
+	code := gjson.GetBytes(respBody, "code").Int()
+	if statusCode == 200 && code == 0 {
+		return nil
+	}
+	if code == 11 { // auth_token无效
+		o.token.reset()
+	}
+	if code == 10000 { // registration_id无效
+		return ErrInvalidToken
+	}
+	return newPushError(o.Name(), statusCode, respBody)
+}
+
+func (o *OPPO) auth() (string, time.Duration, error) {
+	timestamp := fmt.Sprintf("%d", time.Now().UnixNano()/int64(time.Millisecond))
+	signBytes := sha256.Sum256([]byte(o.cfg.AppKey + timestamp + o.cfg.MasterSecret))
+	statusCode, respBody, err := postForm(o.baseURL+"/server/v1/auth", url.Values{
+		"app_key":   {o.cfg.AppKey},
+		"sign":      {hex.EncodeToString(signBytes[:])},
+		"timestamp": {timestamp},
+	}, nil)
+	if err != nil {
+		return "", 0, err
+	}
+	token := gjson.GetBytes(respBody, "data.auth_token").String()
+	if token == "" {
+		return "", 0, newPushError(o.Name(), statusCode, respBody)
+	}
+	// auth_token有效期为一天
+	return token, time.Hour * 24, nil
+}
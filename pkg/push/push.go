@@ -0,0 +1,124 @@
+package push
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 设备类型（对应推送厂商）
+const (
+	DeviceTypeIOS      = "IOS"      // 苹果
+	DeviceTypeHMS      = "HMS"      // 华为
+	DeviceTypeMI       = "MI"       // 小米
+	DeviceTypeVIVO     = "VIVO"     // vivo
+	DeviceTypeOPPO     = "OPPO"     // oppo
+	DeviceTypeFIREBASE = "FIREBASE" // firebase
+)
+
+// ErrInvalidToken 设备token已失效（应用被卸载或token过期），调用方应删除此token
+var ErrInvalidToken = errors.New("设备token已失效")
+
+// Payload 推送内容
+type Payload struct {
+	Title       string // 标题
+	Content     string // 内容
+	ChannelID   string // 点击推送后打开的频道
+	ChannelType uint8
+}
+
+// Provider 推送厂商
+type Provider interface {
+	// Name 厂商名（与设备类型一致）
+	Name() string
+	// Push 推送给指定设备
+	Push(deviceToken string, payload *Payload) error
+}
+
+// IsValidDeviceType 是否是支持的设备类型
+func IsValidDeviceType(deviceType string) bool {
+	switch deviceType {
+	case DeviceTypeIOS, DeviceTypeHMS, DeviceTypeMI, DeviceTypeVIVO, DeviceTypeOPPO, DeviceTypeFIREBASE:
+		return true
+	}
+	return false
+}
+
+var httpClient = &http.Client{
+	Timeout: time.Second * 10,
+}
+
+func doRequest(client *http.Client, method string, reqURL string, contentType string, body []byte, headers map[string]string) (int, []byte, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, reqURL, bodyReader)
+	if err != nil {
+		return 0, nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, respBody, nil
+}
+
+func postJSON(reqURL string, body []byte, headers map[string]string) (int, []byte, error) {
+	return doRequest(httpClient, http.MethodPost, reqURL, "application/json", body, headers)
+}
+
+func postForm(reqURL string, values url.Values, headers map[string]string) (int, []byte, error) {
+	return doRequest(httpClient, http.MethodPost, reqURL, "application/x-www-form-urlencoded", []byte(values.Encode()), headers)
+}
+
+func newPushError(name string, statusCode int, body []byte) error {
+	return fmt.Errorf("%s推送失败！状态[%d] -> %s", name, statusCode, strings.TrimSpace(string(body)))
+}
+
+// accessToken 厂商鉴权token缓存，过期前重新获取
+type accessToken struct {
+	mu       sync.Mutex
+	token    string
+	expireAt time.Time
+}
+
+func (a *accessToken) get(fetch func() (string, time.Duration, error)) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token != "" && time.Now().Before(a.expireAt) {
+		return a.token, nil
+	}
+	token, expire, err := fetch()
+	if err != nil {
+		return "", err
+	}
+	a.token = token
+	// 提前一分钟过期，防止临界时间token失效
+	a.expireAt = time.Now().Add(expire - time.Minute)
+	return token, nil
+}
+
+// 清除缓存的token（厂商返回鉴权失败时调用）
+func (a *accessToken) reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.token = ""
+}
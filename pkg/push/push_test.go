@@ -0,0 +1,63 @@
+package push
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMIPush(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "key=secret", r.Header.Get("Authorization"))
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "token1", r.PostForm.Get("registration_id"))
+		assert.Equal(t, "标题", r.PostForm.Get("title"))
+		w.Write([]byte(`{"result":"ok","code":0}`))
+	}))
+	defer s.Close()
+
+	mi := NewMI(config.MIPush{AppSecret: "secret", PackageName: "com.test"})
+	mi.pushURL = s.URL
+	err := mi.Push("token1", &Payload{Title: "标题", Content: "内容"})
+	assert.NoError(t, err)
+}
+
+func TestMIPushInvalidToken(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result":"error","code":20301}`))
+	}))
+	defer s.Close()
+
+	mi := NewMI(config.MIPush{AppSecret: "secret"})
+	mi.pushURL = s.URL
+	err := mi.Push("token1", &Payload{Title: "标题", Content: "内容"})
+	assert.Equal(t, ErrInvalidToken, err)
+}
+
+func TestVIVOPushAuthCache(t *testing.T) {
+	var authCount int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/message/auth":
+			atomic.AddInt32(&authCount, 1)
+			w.Write([]byte(`{"result":0,"authToken":"auth1"}`))
+		case "/message/send":
+			assert.Equal(t, "auth1", r.Header.Get("authToken"))
+			w.Write([]byte(`{"result":0,"taskId":"1"}`))
+		}
+	}))
+	defer s.Close()
+
+	vivo := NewVIVO(config.VIVOPush{AppID: "1", AppKey: "key", AppSecret: "secret"})
+	vivo.baseURL = s.URL
+	for i := 0; i < 3; i++ {
+		err := vivo.Push("token1", &Payload{Title: "标题", Content: "内容"})
+		assert.NoError(t, err)
+	}
+	// authToken被缓存，只请求一次
+	assert.Equal(t, int32(1), atomic.LoadInt32(&authCount))
+}
@@ -0,0 +1,83 @@
+package push
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/TangSengDaoDao/TangSengDaoDaoServerLib/config"
+	"github.com/WuKongIM/WuKongIMBusinessExtra/pkg/util"
+	"github.com/tidwall/gjson"
+)
+
+// VIVO vivo推送
+type VIVO struct {
+	cfg     config.VIVOPush
+	baseURL string
+	token   accessToken
+}
+
+// NewVIVO 创建vivo推送
+func NewVIVO(cfg config.VIVOPush) *VIVO {
+	return &VIVO{
+		cfg:     cfg,
+		baseURL: "https://api-push.vivo.com.cn",
+	}
+}
+
+func (v *VIVO) Name() string {
+	return DeviceTypeVIVO
+}
+
+func (v *VIVO) Push(deviceToken string, payload *Payload) error {
+	token, err := v.token.get(v.auth)
+	if err != nil {
+		return err
+	}
+	body := map[string]interface{}{
+		"regId":          deviceToken,
+		"notifyType":     4, // 声音和振动
+		"title":          payload.Title,
+		"content":        payload.Content,
+		"skipType":       1, // 打开应用首页
+		"requestId":      strconv.FormatInt(time.Now().UnixNano(), 10),
+		"classification": 1, // 即时消息
+	}
+	statusCode, respBody, err := postJSON(v.baseURL+"/message/send", []byte(util.ToJson(body)), map[string]string{
+		"authToken": token,
+	})
+	if err != nil {
+		return err
+	}
+	result := gjson.GetBytes(respBody, "result").Int()
+	if statusCode == 200 && result == 0 {
+		return nil
+	}
+	if result == 10000 { // authToken失效
+		v.token.reset()
+	}
+	if result == 10302 { // regId不合法
+		return ErrInvalidToken
+	}
+	return newPushError(v.Name(), statusCode, respBody)
+}
+
+func (v *VIVO) auth() (string, time.Duration, error) {
+	timestamp := time.Now().UnixNano() / int64(time.Millisecond)
+	sign := util.MD5(fmt.Sprintf("%s%s%d%s", v.cfg.AppID, v.cfg.AppKey, timestamp, v.cfg.AppSecret))
+	statusCode, respBody, err := postJSON(v.baseURL+"/message/auth", []byte(util.ToJson(map[string]interface{}{
+		"appId":     v.cfg.AppID,
+		"appKey":    v.cfg.AppKey,
+		"timestamp": timestamp,
+		"sign":      sign,
+	})), nil)
+	if err != nil {
+		return "", 0, err
+	}
+	token := gjson.GetBytes(respBody, "authToken").String()
+	if token == "" {
+		return "", 0, newPushError(v.Name(), statusCode, respBody)
+	}
+	// authToken有效期为一天
+	return token, time.Hour * 24, nil
+}